@@ -28,17 +28,41 @@ import (
 	"strings"
 
 	"github.com/SENERGY-Platform/device-command/pkg/api/util"
+	"github.com/SENERGY-Platform/device-command/pkg/audit"
 	"github.com/SENERGY-Platform/device-command/pkg/auth"
 	"github.com/SENERGY-Platform/device-command/pkg/command"
 	"github.com/SENERGY-Platform/device-command/pkg/command/metrics"
 	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/device-command/pkg/tasks"
+	"github.com/SENERGY-Platform/external-task-worker/lib/messages"
 	"github.com/SENERGY-Platform/service-commons/pkg/accesslog"
 	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type Command interface {
-	Command(token auth.Token, cmd command.CommandMessage, timeout string, preferEventValue bool) (code int, resp interface{})
-	Batch(token auth.Token, batch command.BatchRequest, timeout string, preferEventValue bool) []command.BatchResultElement
+	Command(ctx context.Context, token auth.Token, cmd command.CommandMessage, timeout string, preferEventValue bool) (code int, resp interface{})
+	Batch(ctx context.Context, token auth.Token, batch command.BatchRequest, timeout string, preferEventValue bool) []command.BatchResultElement
+	Sequence(ctx context.Context, token auth.Token, request command.SequenceRequest, timeout string, preferEventValue bool) command.SequenceResult
+	Plan(ctx context.Context, token auth.Token, cmd command.CommandMessage, timeout string, preferEventValue bool) (code int, resp interface{})
+	PlanBatch(ctx context.Context, token auth.Token, batch command.BatchRequest, timeout string, preferEventValue bool) []command.BatchResultElement
+	CommandAsync(ctx context.Context, token auth.Token, cmd command.CommandMessage, timeout string, preferEventValue bool) tasks.Task
+	BatchWithListener(ctx context.Context, token auth.Token, batch command.BatchRequest, timeout string, preferEventValue bool, listener func(index int, element command.BatchResultElement)) []command.BatchResultElement
+	BatchAsync(ctx context.Context, token auth.Token, batch command.BatchRequest, timeout string, preferEventValue bool, callbackUrl string) tasks.Task
+	Idempotent(ctx context.Context, token auth.Token, endpoint string, key string, request interface{}, f func() (code int, resp interface{})) (code int, resp interface{}, replayed bool, err error)
+	GetDeviceHistory(ctx context.Context, token auth.Token, deviceId string) (code int, resp interface{})
+	QueryAudit(token auth.Token, query audit.Query) ([]audit.Record, error)
+	GetTask(token auth.Token, id string) (tasks.Task, error)
+	DeleteTask(token auth.Token, id string) error
+	CreateSchedule(token auth.Token, request command.ScheduleRequest) (command.Schedule, error)
+	ListSchedules(token auth.Token) []command.Schedule
+	GetSchedule(token auth.Token, id string) (command.Schedule, error)
+	DeleteSchedule(token auth.Token, id string) error
+	HandleLocalTaskResponse(msg messages.ProtocolMsg) error
+	HandleLocalErrorMessage(msg messages.ProtocolMsg) error
+	ValidForwardSecret(secret string) bool
+	ValidateCallbackUrl(ctx context.Context, callbackUrl string) error
+	Health(ctx context.Context) (ready bool, report command.HealthReport)
 	GetMetricsHttpHandler() *metrics.Metrics
 }
 
@@ -69,6 +93,34 @@ func Start(ctx context.Context, config configuration.Config, command Command) (e
 	return
 }
 
+// routeTemplate returns the registered route of request (e.g. /commands/:task_id), so that span names do not contain ids
+func routeTemplate(router *httprouter.Router, request *http.Request) string {
+	handle, params, _ := router.Lookup(request.Method, request.URL.Path)
+	if handle == nil {
+		return "unknown"
+	}
+	path := request.URL.Path
+	suffix := ""
+	if len(params) > 0 && strings.HasPrefix(params[len(params)-1].Value, "/") {
+		catchAll := params[len(params)-1]
+		path = strings.TrimSuffix(path, catchAll.Value)
+		suffix = "/*" + catchAll.Key
+		params = params[:len(params)-1]
+	}
+	segments := strings.Split(path, "/")
+	next := 0
+	for _, param := range params {
+		for i := next; i < len(segments); i++ {
+			if segments[i] == param.Value {
+				segments[i] = ":" + param.Key
+				next = i + 1
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/") + suffix
+}
+
 func GetRouter(config configuration.Config, command Command) (handler http.Handler, err error) {
 	router := httprouter.New()
 	router.Handler(http.MethodGet, "/metrics", command.GetMetricsHttpHandler())
@@ -92,8 +144,12 @@ func GetRouter(config configuration.Config, command Command) (handler http.Handl
 	default:
 		return handler, errors.New("unknown request_user_idp configured")
 	}
+	handler = otelhttp.NewHandler(handler, "device-command", otelhttp.WithSpanNameFormatter(func(operation string, request *http.Request) string {
+		return request.Method + " " + routeTemplate(router, request)
+	}))
 	handler = util.NewVersionHeaderMiddleware(handler)
 	handler = util.NewCors(handler)
 	handler = accesslog.New(handler)
+	handler = util.NewFlushMiddleware(handler)
 	return handler, nil
 }
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/audit"
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/julienschmidt/httprouter"
+)
+
+func init() {
+	endpoints = append(endpoints, AuditEndpoints)
+}
+
+func AuditEndpoints(config configuration.Config, router *httprouter.Router, cmd Command) {
+	// query parameters: device_id, user_id (only the own user id is allowed), from and to (RFC3339), limit
+	router.GET("/audit", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		cmd.GetMetricsHttpHandler().LogRequest(token.GetUserId(), "GET /audit")
+
+		userId := request.URL.Query().Get("user_id")
+		if userId != "" && userId != token.GetUserId() {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusForbidden, "response-body", "access to audit records of other users is not allowed")
+			http.Error(writer, "access to audit records of other users is not allowed", http.StatusForbidden)
+			return
+		}
+
+		query := audit.Query{DeviceId: request.URL.Query().Get("device_id")}
+		if from := request.URL.Query().Get("from"); from != "" {
+			query.From, err = time.Parse(time.RFC3339, from)
+		}
+		if to := request.URL.Query().Get("to"); err == nil && to != "" {
+			query.To, err = time.Parse(time.RFC3339, to)
+		}
+		if limit := request.URL.Query().Get("limit"); err == nil && limit != "" {
+			query.Limit, err = strconv.Atoi(limit)
+		}
+		if err != nil {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		records, err := cmd.QueryAudit(token, query)
+		if errors.Is(err, audit.ErrQueryNotSupported) {
+			http.Error(writer, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusInternalServerError, "response-body", err.Error())
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(writer).Encode(records)
+	})
+}
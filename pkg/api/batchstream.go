@@ -0,0 +1,111 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SENERGY-Platform/device-command/pkg/api/util"
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/command"
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/julienschmidt/httprouter"
+)
+
+func init() {
+	endpoints = append(endpoints, BatchStreamEndpoints)
+}
+
+// BatchStreamEndpoints streams each batch result as soon as it is available.
+// the default format is server-sent events; ?format=ndjson or 'Accept: application/x-ndjson' switches to newline delimited json.
+func BatchStreamEndpoints(config configuration.Config, router *httprouter.Router, cmd Command) {
+	router.POST("/commands/batch/stream", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		cmd.GetMetricsHttpHandler().LogRequest(token.GetUserId(), "POST /commands/batch/stream")
+
+		preferEventValueStr := request.URL.Query().Get("prefer_event_value")
+		preferEventValue := false
+		if preferEventValueStr != "" {
+			preferEventValue, err = strconv.ParseBool(preferEventValueStr)
+			if err != nil {
+				config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		timeout := request.URL.Query().Get("timeout")
+		batch := command.BatchRequest{}
+		err = json.NewDecoder(request.Body).Decode(&batch)
+		if err != nil {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = batch.Validate()
+		if err != nil {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ndjson := request.URL.Query().Get("format") == "ndjson" || strings.Contains(request.Header.Get("Accept"), "application/x-ndjson")
+		if ndjson {
+			writer.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+		} else {
+			writer.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+			writer.Header().Set("Cache-Control", "no-cache")
+		}
+		writer.WriteHeader(http.StatusOK)
+		util.Flush(writer, request)
+
+		summary := command.BatchStreamSummary{Total: len(batch)}
+		cmd.BatchWithListener(request.Context(), token, batch, timeout, preferEventValue, func(index int, element command.BatchResultElement) {
+			if element.StatusCode == http.StatusOK {
+				summary.Succeeded++
+			} else {
+				summary.Failed++
+			}
+			writeStreamEvent(writer, ndjson, "result", command.BatchStreamElement{Index: index, BatchResultElement: element})
+			util.Flush(writer, request)
+		})
+		writeStreamEvent(writer, ndjson, "summary", summary)
+		util.Flush(writer, request)
+	})
+}
+
+func writeStreamEvent(writer http.ResponseWriter, ndjson bool, event string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(err.Error())
+	}
+	if ndjson {
+		line, _ := json.Marshal(map[string]json.RawMessage{event: data})
+		writer.Write(append(line, '\n'))
+		return
+	}
+	writer.Write([]byte("event: " + event + "\ndata: " + string(data) + "\n\n"))
+}
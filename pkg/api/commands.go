@@ -18,6 +18,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -25,6 +26,9 @@ import (
 	"github.com/SENERGY-Platform/device-command/pkg/auth"
 	"github.com/SENERGY-Platform/device-command/pkg/command"
 	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/device-command/pkg/idempotency"
+	"github.com/SENERGY-Platform/device-command/pkg/status"
+	"github.com/SENERGY-Platform/device-command/pkg/tasks"
 	"github.com/julienschmidt/httprouter"
 )
 
@@ -62,16 +66,81 @@ func CommandEndpoints(config configuration.Config, router *httprouter.Router, cm
 			return
 		}
 
-		if msg.FunctionId == "" {
-			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", "expect function_id in body")
-			http.Error(writer, "expect function_id in body", http.StatusBadRequest)
-			return
+		query := request.URL.Query()
+		asyncStr := query.Get("async")
+		async := false
+		if asyncStr != "" {
+			async, err = strconv.ParseBool(asyncStr)
+			if err != nil {
+				config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if maxAge := query.Get("max_age"); maxAge != "" {
+			msg.MaxAge = maxAge
+		}
+		if returnPreviousStr := query.Get("return_previous"); returnPreviousStr != "" {
+			msg.ReturnPrevious, err = strconv.ParseBool(returnPreviousStr)
+			if err != nil {
+				config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if groupResultDetailsStr := query.Get("group_result_details"); groupResultDetailsStr != "" {
+			msg.GroupResultDetails, err = strconv.ParseBool(groupResultDetailsStr)
+			if err != nil {
+				config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
 		}
 
-		code, result := cmd.Command(token, msg, timeout, preferEventValue)
+		err = msg.Validate()
+		if err == nil && msg.CallbackUrl != "" {
+			err = cmd.ValidateCallbackUrl(request.Context(), msg.CallbackUrl)
+		}
+		if err != nil {
+			writeInvalidRequest(config, writer, request, token, err, command.AffectedIds{DeviceId: msg.DeviceId, ServiceId: msg.ServiceId, FunctionId: msg.FunctionId, GroupId: msg.GroupId})
+			return
+		}
+		ctx, timings := command.WithStageTimings(request.Context())
+		code, result, replayed, err := cmd.Idempotent(request.Context(), token, "POST /commands", request.Header.Get("Idempotency-Key"), idempotentRequest(request, msg), func() (int, interface{}) {
+			if async || msg.CallbackUrl != "" {
+				return http.StatusAccepted, cmd.CommandAsync(request.Context(), token, msg, timeout, preferEventValue)
+			}
+			return cmd.Command(ctx, token, msg, timeout, preferEventValue)
+		})
+		if err != nil {
+			writeIdempotencyError(config, writer, request, token, err)
+			return
+		}
+		if replayed {
+			writer.Header().Set("Idempotent-Replayed", "true")
+		}
+		if task, ok := result.(tasks.Task); ok {
+			writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+			writer.Header().Set("Location", "/commands/"+task.Id)
+			writer.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(writer).Encode(task)
+			return
+		}
 		if code != http.StatusOK {
 			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", code, "response-body", fmt.Sprintf("%#v", result))
 		}
+		if serverTiming := timings.ServerTiming(); serverTiming != "" {
+			writer.Header().Set("Server-Timing", serverTiming)
+		}
+		if problem, ok := result.(*command.CommandError); ok {
+			if problem.RetryAfter > 0 {
+				writer.Header().Set("Retry-After", strconv.FormatInt(problem.RetryAfter, 10))
+			}
+			writer.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+			writer.WriteHeader(code)
+			json.NewEncoder(writer).Encode(problem)
+			return
+		}
 		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
 		writer.WriteHeader(code)
 		json.NewEncoder(writer).Encode(result)
@@ -113,9 +182,125 @@ func CommandEndpoints(config configuration.Config, router *httprouter.Router, cm
 			return
 		}
 
-		result := cmd.Batch(token, batch, timeout, preferEventValue)
+		asyncStr := request.URL.Query().Get("async")
+		async := false
+		if asyncStr != "" {
+			async, err = strconv.ParseBool(asyncStr)
+			if err != nil {
+				config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		callbackUrl := request.URL.Query().Get("callback_url")
+		if callbackUrl != "" {
+			err = cmd.ValidateCallbackUrl(request.Context(), callbackUrl)
+			if err != nil {
+				config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		_, result, replayed, err := cmd.Idempotent(request.Context(), token, "POST /commands/batch", request.Header.Get("Idempotency-Key"), idempotentRequest(request, batch), func() (int, interface{}) {
+			if async || callbackUrl != "" {
+				return http.StatusAccepted, cmd.BatchAsync(request.Context(), token, batch, timeout, preferEventValue, callbackUrl)
+			}
+			return http.StatusOK, cmd.Batch(request.Context(), token, batch, timeout, preferEventValue)
+		})
+		if err != nil {
+			writeIdempotencyError(config, writer, request, token, err)
+			return
+		}
+		if replayed {
+			writer.Header().Set("Idempotent-Replayed", "true")
+		}
+		if task, ok := result.(tasks.Task); ok {
+			writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+			writer.Header().Set("Location", "/commands/"+task.Id)
+			writer.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(writer).Encode(task)
+			return
+		}
 		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
 		json.NewEncoder(writer).Encode(result)
 		return
 	})
+
+	router.GET("/commands/:task_id", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		cmd.GetMetricsHttpHandler().LogRequest(token.GetUserId(), "GET /commands/:task_id")
+
+		task, err := cmd.GetTask(token, params.ByName("task_id"))
+		if errors.Is(err, tasks.ErrNotFound) {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusInternalServerError, "response-body", err.Error())
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(writer).Encode(task)
+	})
+
+	router.DELETE("/commands/:task_id", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		token, err := auth.GetParsedToken(request)
+		if err != nil {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+			http.Error(writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		cmd.GetMetricsHttpHandler().LogRequest(token.GetUserId(), "DELETE /commands/:task_id")
+
+		err = cmd.DeleteTask(token, params.ByName("task_id"))
+		if errors.Is(err, tasks.ErrNotFound) {
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusInternalServerError, "response-body", err.Error())
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// idempotentRequest identifies a request for the Idempotency-Key header; query parameters are included because they change the execution
+func idempotentRequest(request *http.Request, body interface{}) interface{} {
+	return map[string]interface{}{"query": request.URL.Query(), "body": body}
+}
+
+func writeIdempotencyError(config configuration.Config, writer http.ResponseWriter, request *http.Request, token auth.Token, err error) {
+	if errors.Is(err, idempotency.ErrOutcomeUnknown) {
+		problem := command.NewCommandError(http.StatusConflict, command.ErrCodeOutcomeUnknown, "the first request with this Idempotency-Key was canceled or timed out after its command may have been sent; check the device state and retry with a new key", command.AffectedIds{})
+		config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusConflict, "response-body", err.Error())
+		writer.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+		writer.WriteHeader(http.StatusConflict)
+		json.NewEncoder(writer).Encode(problem)
+		return
+	}
+	code := http.StatusUnprocessableEntity
+	if !errors.Is(err, idempotency.ErrKeyReused) {
+		code = status.ClientClosedRequest
+	}
+	config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", code, "response-body", err.Error())
+	http.Error(writer, err.Error(), code)
+}
+
+// writeInvalidRequest responds with an invalid_request problem for request validation errors
+func writeInvalidRequest(config configuration.Config, writer http.ResponseWriter, request *http.Request, token auth.Token, err error, ids command.AffectedIds) {
+	problem := command.NewCommandError(http.StatusBadRequest, command.ErrCodeInvalidRequest, err.Error(), ids)
+	config.GetLogger().Warn("error response", "request-url", request.URL.String(), "user", token.GetUserId(), "response-status-code", http.StatusBadRequest, "response-body", err.Error())
+	writer.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	writer.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(writer).Encode(problem)
 }
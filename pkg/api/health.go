@@ -17,8 +17,11 @@
 package api
 
 import (
+	"encoding/json"
+	"github.com/SENERGY-Platform/device-command/pkg/command"
 	"github.com/SENERGY-Platform/device-command/pkg/configuration"
 	"github.com/julienschmidt/httprouter"
+	"log"
 	"net/http"
 )
 
@@ -26,8 +29,33 @@ func init() {
 	endpoints = append(endpoints, HealthEndpoint)
 }
 
-func HealthEndpoint(config configuration.Config, router *httprouter.Router, command Command) {
+func HealthEndpoint(config configuration.Config, router *httprouter.Router, cmd Command) {
 	router.GET("/", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
 		writer.WriteHeader(200)
 	})
+
+	//the process is able to handle requests; dependencies are not checked
+	router.GET("/health/live", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		writeHealthReport(writer, http.StatusOK, command.HealthReport{Status: command.HealthStatusOk, Components: map[string]command.ComponentHealth{}})
+	})
+
+	//responds with 503 while a dependency (kafka, mqtt broker, device repository, timescale) is unavailable
+	router.GET("/health/ready", func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+		ready, report := cmd.Health(request.Context())
+		if ready {
+			writeHealthReport(writer, http.StatusOK, report)
+		} else {
+			writeHealthReport(writer, http.StatusServiceUnavailable, report)
+		}
+	})
+}
+
+func writeHealthReport(writer http.ResponseWriter, code int, report command.HealthReport) {
+	writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.WriteHeader(code)
+	err := json.NewEncoder(writer).Encode(report)
+	if err != nil {
+		log.Println("ERROR: unable to encode health report", err)
+	}
 }
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/device-command/pkg/scaling"
+	"github.com/SENERGY-Platform/external-task-worker/lib/messages"
+	"github.com/julienschmidt/httprouter"
+)
+
+func init() {
+	endpoints = append(endpoints, ReplicaEndpoints)
+}
+
+// ReplicaEndpoints receive responses which other replicas consumed from the shared response topic
+func ReplicaEndpoints(config configuration.Config, router *httprouter.Router, cmd Command) {
+	if config.ScalingMode != scaling.ModeShared {
+		return
+	}
+	handle := func(handler func(msg messages.ProtocolMsg) error) httprouter.Handle {
+		return func(writer http.ResponseWriter, request *http.Request, params httprouter.Params) {
+			if !cmd.ValidForwardSecret(request.Header.Get(scaling.SecretHeader)) {
+				http.Error(writer, "invalid forward secret", http.StatusUnauthorized)
+				return
+			}
+			msg := messages.ProtocolMsg{}
+			err := json.NewDecoder(request.Body).Decode(&msg)
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+			err = handler(msg)
+			if err != nil {
+				config.GetLogger().Error("unable to handle forwarded message", "error", err, "task-id", msg.TaskInfo.TaskId)
+				http.Error(writer, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			writer.WriteHeader(http.StatusOK)
+		}
+	}
+	router.POST(scaling.ResponsePath, handle(cmd.HandleLocalTaskResponse))
+	router.POST(scaling.ErrorPath, handle(cmd.HandleLocalErrorMessage))
+}
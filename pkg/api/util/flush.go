@@ -0,0 +1,51 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"net/http"
+)
+
+type flusherCtxKey struct{}
+
+// NewFlushMiddleware remembers the original http.Flusher, which is hidden by response wrappers of following middlewares
+func NewFlushMiddleware(handler http.Handler) http.Handler {
+	return &FlushMiddleware{handler: handler}
+}
+
+type FlushMiddleware struct {
+	handler http.Handler
+}
+
+func (this *FlushMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if flusher, ok := w.(http.Flusher); ok {
+		r = r.WithContext(context.WithValue(r.Context(), flusherCtxKey{}, flusher))
+	}
+	this.handler.ServeHTTP(w, r)
+}
+
+// Flush flushes the response writer or the original http.Flusher remembered by the FlushMiddleware
+func Flush(w http.ResponseWriter, r *http.Request) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+		return
+	}
+	if flusher, ok := r.Context().Value(flusherCtxKey{}).(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
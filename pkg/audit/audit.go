@@ -0,0 +1,94 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+)
+
+var ErrQueryNotSupported = errors.New("audit sink does not support queries")
+
+type Event string
+
+const (
+	EventSend     Event = "send"
+	EventComplete Event = "complete"
+)
+
+// Record describes a command sent to a device (EventSend) or its result (EventComplete); both share the TaskId
+type Record struct {
+	Time             time.Time   `json:"time"`
+	Event            Event       `json:"event"`
+	TaskId           string      `json:"task_id"`
+	UserId           string      `json:"user_id"`
+	DeviceId         string      `json:"device_id"`
+	ServiceId        string      `json:"service_id"`
+	FunctionId       string      `json:"function_id"`
+	AspectId         string      `json:"aspect_id,omitempty"`
+	CharacteristicId string      `json:"characteristic_id,omitempty"`
+	Input            interface{} `json:"input,omitempty"`
+	StatusCode       int         `json:"status_code,omitempty"` //only EventComplete
+	LatencyMs        int64       `json:"latency_ms,omitempty"`  //only EventComplete; time since EventSend
+}
+
+// Query selects records; empty fields do not filter
+type Query struct {
+	UserId   string
+	DeviceId string
+	From     time.Time
+	To       time.Time
+	Limit    int //the newest records are returned if more match
+}
+
+func (this Query) Matches(record Record) bool {
+	if this.UserId != "" && this.UserId != record.UserId {
+		return false
+	}
+	if this.DeviceId != "" && this.DeviceId != record.DeviceId {
+		return false
+	}
+	if !this.From.IsZero() && record.Time.Before(this.From) {
+		return false
+	}
+	if !this.To.IsZero() && record.Time.After(this.To) {
+		return false
+	}
+	return true
+}
+
+type Sink interface {
+	Write(record Record) error
+	Query(query Query) ([]Record, error) //may return ErrQueryNotSupported
+}
+
+// New creates the sink selected by config.AuditSink; returns nil if audit records are disabled
+func New(ctx context.Context, config configuration.Config) (Sink, error) {
+	switch config.AuditSink {
+	case "", "-":
+		return nil, nil
+	case "file":
+		return NewFileSink(ctx, config.AuditFile, config.AuditFileMaxSize)
+	case "kafka":
+		return NewKafkaSink(ctx, config.KafkaUrl, config.AuditKafkaTopic)
+	default:
+		return nil, errors.New("unknown audit_sink " + config.AuditSink)
+	}
+}
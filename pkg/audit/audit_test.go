@@ -0,0 +1,191 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFileSink(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sink, err := NewFileSink(ctx, filepath.Join(t.TempDir(), "audit", "audit.log"), 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result, err := sink.Query(Query{UserId: "user1"})
+	if err != nil || len(result) != 0 {
+		t.Error(result, err)
+		return
+	}
+
+	start := time.Now()
+	records := []Record{
+		{Time: start, Event: EventSend, TaskId: "1", UserId: "user1", DeviceId: "d1"},
+		{Time: start.Add(time.Second), Event: EventComplete, TaskId: "1", UserId: "user1", DeviceId: "d1", StatusCode: 200},
+		{Time: start.Add(2 * time.Second), Event: EventSend, TaskId: "2", UserId: "user2", DeviceId: "d1"},
+		{Time: start.Add(3 * time.Second), Event: EventSend, TaskId: "3", UserId: "user1", DeviceId: "d2"},
+	}
+	for _, record := range records {
+		err = sink.Write(record)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	result, err = sink.Query(Query{UserId: "user1"})
+	if err != nil || len(result) != 3 {
+		t.Error(result, err)
+		return
+	}
+	result, err = sink.Query(Query{UserId: "user1", DeviceId: "d1"})
+	if err != nil || len(result) != 2 || result[1].StatusCode != 200 {
+		t.Error(result, err)
+		return
+	}
+	result, err = sink.Query(Query{UserId: "user1", From: start.Add(500 * time.Millisecond)})
+	if err != nil || len(result) != 2 || result[0].TaskId != "1" || result[1].TaskId != "3" {
+		t.Error(result, err)
+		return
+	}
+	result, err = sink.Query(Query{To: start.Add(2 * time.Second), Limit: 2})
+	if err != nil || len(result) != 2 || result[0].Event != EventComplete || result[1].TaskId != "2" {
+		t.Error(result, err)
+		return
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	location := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(ctx, location, 1000)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	start := time.Now()
+	for i := 0; i < 50; i++ {
+		err = sink.Write(Record{Time: start.Add(time.Duration(i) * time.Second), Event: EventSend, TaskId: strconv.Itoa(i), UserId: "user1"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	sink.flush()
+	for _, file := range []string{location, location + ".1"} {
+		info, err := os.Stat(file)
+		if err != nil || info.Size() > 1000 {
+			t.Error(file, info, err)
+			return
+		}
+	}
+
+	result, err := sink.Query(Query{UserId: "user1"})
+	if err != nil || len(result) == 0 || len(result) == 50 || result[len(result)-1].TaskId != "49" {
+		t.Error(len(result), err)
+		return
+	}
+	for i := 1; i < len(result); i++ {
+		if !result[i].Time.After(result[i-1].Time) {
+			t.Error("expected records in write order", result[i-1], result[i])
+			return
+		}
+	}
+}
+
+func TestFileSinkIgnoresIncompleteLine(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	location := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(ctx, location, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = sink.Write(Record{Time: time.Now(), Event: EventSend, TaskId: "1", UserId: "user1"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sink.flush()
+	file, err := os.OpenFile(location, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = file.WriteString(`{"time":"`)
+	file.Close()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	result, err := sink.Query(Query{UserId: "user1"})
+	if err != nil || len(result) != 1 {
+		t.Error(result, err)
+		return
+	}
+}
+
+func TestFileSinkFlushesOnClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	location := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(ctx, location, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 100; i++ {
+		err = sink.Write(Record{Time: time.Now(), Event: EventSend, TaskId: strconv.Itoa(i), UserId: "user1"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	cancel()
+	<-sink.done
+
+	err = sink.Write(Record{Time: time.Now(), Event: EventSend, TaskId: "late", UserId: "user1"})
+	if !errors.Is(err, ErrSinkClosed) {
+		t.Error(err)
+	}
+	file, err := os.Open(location)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+	}
+	if lines != 100 {
+		t.Error(lines)
+	}
+}
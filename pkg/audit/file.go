@@ -0,0 +1,260 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var ErrSinkClosed = errors.New("audit sink closed")
+
+const fileSinkQueueSize = 1000
+const fileSinkFlushInterval = time.Second
+
+// FileSink appends records as json lines to a file.
+// records are written by a background worker which keeps the file open and flushes its buffer when no records are waiting,
+// after fileSinkFlushInterval and before each Query; Write only blocks if fileSinkQueueSize records are waiting.
+// if appending would exceed maxSize, the file is rotated to location+".1", replacing the previously rotated file;
+// so at most 2*maxSize bytes are kept. maxSize <= 0 disables the rotation.
+// the file is closed when ctx is done; records written after that are rejected with ErrSinkClosed.
+type FileSink struct {
+	location string
+	maxSize  int64
+	queue    chan fileSinkItem
+	done     chan struct{}
+
+	//only used by the worker
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+}
+
+// fileSinkItem is either a line to write or a flush request, which is closed after all previous lines are flushed
+type fileSinkItem struct {
+	line    []byte
+	flushed chan struct{}
+}
+
+func NewFileSink(ctx context.Context, location string, maxSize int64) (*FileSink, error) {
+	err := os.MkdirAll(filepath.Dir(location), 0755)
+	if err != nil {
+		return nil, err
+	}
+	result := &FileSink{location: location, maxSize: maxSize, queue: make(chan fileSinkItem, fileSinkQueueSize), done: make(chan struct{})}
+	err = result.open()
+	if err != nil {
+		return nil, err
+	}
+	go result.loop(ctx)
+	return result, nil
+}
+
+func (this *FileSink) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-this.done:
+		return ErrSinkClosed
+	default:
+	}
+	select {
+	case this.queue <- fileSinkItem{line: append(line, '\n')}:
+		return nil
+	case <-this.done:
+		return ErrSinkClosed
+	}
+}
+
+// flush waits until all records written before are flushed to the file
+func (this *FileSink) flush() {
+	flushed := make(chan struct{})
+	select {
+	case this.queue <- fileSinkItem{flushed: flushed}:
+	case <-this.done:
+		return //closing flushes the buffer
+	}
+	select {
+	case <-flushed:
+	case <-this.done:
+	}
+}
+
+func (this *FileSink) loop(ctx context.Context) {
+	defer close(this.done)
+	defer this.close()
+	ticker := time.NewTicker(fileSinkFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			this.drain()
+			return
+		case <-ticker.C:
+			this.flushBuffer()
+		case item := <-this.queue:
+			this.handle(item)
+			if len(this.queue) == 0 {
+				this.flushBuffer()
+			}
+		}
+	}
+}
+
+// drain writes the records which are already waiting
+func (this *FileSink) drain() {
+	for {
+		select {
+		case item := <-this.queue:
+			this.handle(item)
+		default:
+			return
+		}
+	}
+}
+
+func (this *FileSink) handle(item fileSinkItem) {
+	if item.flushed != nil {
+		this.flushBuffer()
+		close(item.flushed)
+		return
+	}
+	err := this.write(item.line)
+	if err != nil {
+		log.Println("ERROR: unable to write audit record", err)
+	}
+}
+
+func (this *FileSink) write(line []byte) error {
+	err := this.rotate(int64(len(line)))
+	if err != nil {
+		return err
+	}
+	if this.writer == nil {
+		err = this.open()
+		if err != nil {
+			return err
+		}
+	}
+	n, err := this.writer.Write(line)
+	this.size = this.size + int64(n)
+	return err
+}
+
+func (this *FileSink) flushBuffer() {
+	if this.writer == nil {
+		return
+	}
+	err := this.writer.Flush()
+	if err != nil {
+		log.Println("ERROR: unable to flush audit records", err)
+	}
+}
+
+func (this *FileSink) open() error {
+	file, err := os.OpenFile(this.location, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	this.file, this.writer, this.size = file, bufio.NewWriterSize(file, 64*1024), info.Size()
+	return nil
+}
+
+func (this *FileSink) close() {
+	if this.writer == nil {
+		return
+	}
+	this.flushBuffer()
+	err := this.file.Close()
+	if err != nil {
+		log.Println("ERROR: unable to close audit file", err)
+	}
+	this.file, this.writer = nil, nil
+}
+
+// rotate is only called by the worker
+func (this *FileSink) rotate(size int64) error {
+	if this.maxSize <= 0 || this.size == 0 || this.size+size <= this.maxSize {
+		return nil
+	}
+	this.close()
+	err := os.Rename(this.location, this.location+".1")
+	if err != nil {
+		return err
+	}
+	return this.open()
+}
+
+// Query flushes the records written before and reads the rotated and the current file without blocking writers.
+// a line which is still being written is ignored; records written during a rotation may be missing from the result.
+func (this *FileSink) Query(query Query) (result []Record, err error) {
+	this.flush()
+	result = []Record{}
+	for _, location := range []string{this.location + ".1", this.location} {
+		result, err = queryFile(location, query, result)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func queryFile(location string, query Query, result []Record) ([]Record, error) {
+	file, err := os.Open(location)
+	if os.IsNotExist(err) {
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+	reader := bufio.NewReaderSize(file, 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return result, nil //incomplete lines are not yet fully written
+		}
+		if err != nil {
+			return result, err
+		}
+		record := Record{}
+		err = json.Unmarshal(line, &record)
+		if err != nil {
+			return result, err
+		}
+		if query.Matches(record) {
+			result = append(result, record)
+			if query.Limit > 0 && len(result) > query.Limit {
+				result = result[1:]
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSink produces records to a kafka topic, keyed by device id; records are consumed by other services and can not be queried
+type KafkaSink struct {
+	ctx    context.Context
+	writer *kafka.Writer
+}
+
+func NewKafkaSink(ctx context.Context, kafkaUrl string, topic string) (*KafkaSink, error) {
+	writer := &kafka.Writer{
+		Addr:                   kafka.TCP(kafkaUrl),
+		Topic:                  topic,
+		Balancer:               &kafka.Hash{},
+		Async:                  true,
+		AllowAutoTopicCreation: true,
+		Completion: func(messages []kafka.Message, err error) {
+			if err != nil {
+				log.Println("ERROR: unable to produce audit records", len(messages), err)
+			}
+		},
+	}
+	go func() {
+		<-ctx.Done()
+		writer.Close()
+	}()
+	return &KafkaSink{ctx: ctx, writer: writer}, nil
+}
+
+func (this *KafkaSink) Write(record Record) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return this.writer.WriteMessages(this.ctx, kafka.Message{Key: []byte(record.DeviceId), Value: value})
+}
+
+func (this *KafkaSink) Query(query Query) ([]Record, error) {
+	return nil, ErrQueryNotSupported
+}
@@ -17,11 +17,28 @@
 package auth
 
 import (
+	"time"
+
 	"github.com/SENERGY-Platform/service-commons/pkg/jwt"
+	jwtlib "github.com/golang-jwt/jwt"
 )
 
 var GetAuthToken = jwt.GetAuthToken
 var GetParsedToken = jwt.GetParsedToken
 var Parse = jwt.Parse
+var ExchangeUserToken = jwt.ExchangeUserToken
 
 type Token = jwt.Token
+
+// GetExpiration reads the exp claim of token without verifying its signature; ok is false if the token has no exp claim
+func GetExpiration(token Token) (expiration time.Time, ok bool, err error) {
+	claims := jwtlib.StandardClaims{}
+	_, _, err = new(jwtlib.Parser).ParseUnverified(token.Jwt(), &claims)
+	if err != nil {
+		return expiration, false, err
+	}
+	if claims.ExpiresAt == 0 {
+		return expiration, false, nil
+	}
+	return time.Unix(claims.ExpiresAt, 0), true, nil
+}
@@ -0,0 +1,256 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package callback
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+)
+
+// ErrHostNotAllowed is returned for callback urls which are not in callback_allowed_hosts
+// or which resolve to loopback, private or link-local addresses
+var ErrHostNotAllowed = errors.New("callback_url host not allowed")
+
+const SignatureHeader = "X-Device-Command-Signature"
+const TimestampHeader = "X-Device-Command-Timestamp"
+
+type Message struct {
+	TaskId     string      `json:"task_id,omitempty"`
+	BatchIndex *int        `json:"batch_index,omitempty"`
+	StatusCode int         `json:"status_code"`
+	Message    interface{} `json:"message"`
+}
+
+type Sender struct {
+	client         *http.Client
+	secret         []byte
+	maxRetries     int64
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	allowedHosts   []string
+	dialer         *net.Dialer
+	debug          bool
+}
+
+func New(config configuration.Config) (*Sender, error) {
+	var err error
+	initialBackoff := time.Second
+	if config.CallbackInitialBackoff != "" && config.CallbackInitialBackoff != "-" {
+		initialBackoff, err = time.ParseDuration(config.CallbackInitialBackoff)
+		if err != nil {
+			return nil, err
+		}
+	}
+	maxBackoff := time.Minute
+	if config.CallbackMaxBackoff != "" && config.CallbackMaxBackoff != "-" {
+		maxBackoff, err = time.ParseDuration(config.CallbackMaxBackoff)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result := &Sender{
+		secret:         []byte(config.CallbackSecret),
+		maxRetries:     config.CallbackMaxRetries,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+		allowedHosts:   config.CallbackAllowedHosts,
+		dialer:         &net.Dialer{Timeout: 10 * time.Second},
+		debug:          config.Debug,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil //a proxy would resolve the host and bypass the address check of dialContext
+	transport.DialContext = result.dialContext
+	result.client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	return result, nil
+}
+
+func ValidateUrl(callbackUrl string) error {
+	parsed, err := url.Parse(callbackUrl)
+	if err != nil {
+		return fmt.Errorf("invalid callback_url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return errors.New("invalid callback_url: expect http or https scheme")
+	}
+	if parsed.Host == "" {
+		return errors.New("invalid callback_url: missing host")
+	}
+	return nil
+}
+
+// Check validates the url and ensures that its host is allowed; the host is resolved to check its addresses
+func (this *Sender) Check(ctx context.Context, callbackUrl string) error {
+	err := ValidateUrl(callbackUrl)
+	if err != nil {
+		return err
+	}
+	parsed, _ := url.Parse(callbackUrl)
+	_, err = this.resolve(ctx, parsed.Hostname())
+	if err != nil {
+		return fmt.Errorf("invalid callback_url: %w", err)
+	}
+	return nil
+}
+
+// listed checks host against callback_allowed_hosts; entries starting with "." match all subdomains
+func (this *Sender) listed(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range this.allowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the addresses of host; hosts in callback_allowed_hosts are trusted and returned unresolved.
+// if callback_allowed_hosts is empty, every host resolving only to public addresses is allowed.
+func (this *Sender) resolve(ctx context.Context, host string) ([]string, error) {
+	if this.listed(host) {
+		return []string{host}, nil
+	}
+	if len(this.allowedHosts) > 0 {
+		return nil, ErrHostNotAllowed
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("unable to resolve " + host)
+	}
+	result := []string{}
+	for _, addr := range addrs {
+		if !isPublic(addr.IP) {
+			return nil, ErrHostNotAllowed
+		}
+		result = append(result, addr.IP.String())
+	}
+	return result, nil
+}
+
+// dialContext connects only to the checked addresses of resolve, to prevent dns rebinding between check and connect
+func (this *Sender) dialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := this.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hosts {
+		var conn net.Conn
+		conn, err = this.dialer.DialContext(ctx, network, net.JoinHostPort(h, port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+// Send posts the message to the callback url in the background; failed attempts are retried with exponential backoff up to callback_max_backoff
+func (this *Sender) Send(callbackUrl string, msg Message) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		log.Println("ERROR: unable to marshal callback message", err)
+		return
+	}
+	go func() {
+		backoff := this.initialBackoff
+		for attempt := int64(0); attempt <= this.maxRetries; attempt++ {
+			if attempt > 0 {
+				time.Sleep(backoff)
+				backoff = min(backoff*2, this.maxBackoff)
+			}
+			err := this.send(callbackUrl, body)
+			if err == nil {
+				return
+			}
+			if errors.Is(err, ErrHostNotAllowed) {
+				log.Println("ERROR: callback", callbackUrl, msg.TaskId, err)
+				return
+			}
+			log.Println("WARNING: unable to send callback", callbackUrl, "attempt", attempt+1, err)
+		}
+		log.Println("ERROR: giving up on callback", callbackUrl, msg.TaskId)
+	}()
+}
+
+func (this *Sender) send(callbackUrl string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, callbackUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set(TimestampHeader, timestamp)
+	if len(this.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(this.secret, timestamp, body))
+	}
+	resp, err := this.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		temp, _ := io.ReadAll(resp.Body)
+		return errors.New(strconv.Itoa(resp.StatusCode) + ": " + strings.TrimSpace(string(temp)))
+	}
+	if this.debug {
+		log.Println("DEBUG: callback send", callbackUrl, string(body))
+	}
+	return nil
+}
+
+// Sign computes the hex encoded HMAC-SHA256 of "<timestamp>.<body>"
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
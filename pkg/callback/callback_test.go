@@ -0,0 +1,130 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+)
+
+func TestSenderRetryAndSignature(t *testing.T) {
+	mux := sync.Mutex{}
+	calls := 0
+	received := make(chan Message, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mux.Lock()
+		calls++
+		call := calls
+		mux.Unlock()
+		if call < 3 {
+			http.Error(writer, "not yet", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(request.Body)
+		expected := "sha256=" + Sign([]byte("secret"), request.Header.Get(TimestampHeader), body)
+		if request.Header.Get(SignatureHeader) != expected {
+			t.Error("unexpected signature", request.Header.Get(SignatureHeader), expected)
+		}
+		msg := Message{}
+		err := json.Unmarshal(body, &msg)
+		if err != nil {
+			t.Error(err)
+		}
+		received <- msg
+	}))
+	defer server.Close()
+
+	sender, err := New(configuration.Config{CallbackSecret: "secret", CallbackMaxRetries: 3, CallbackInitialBackoff: "10ms", CallbackAllowedHosts: []string{"127.0.0.1"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	sender.Send(server.URL, Message{TaskId: "task", StatusCode: 200, Message: "foo"})
+
+	select {
+	case msg := <-received:
+		if msg.TaskId != "task" || msg.StatusCode != 200 || msg.Message != "foo" {
+			t.Errorf("%#v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("timeout")
+	}
+}
+
+func TestValidateUrl(t *testing.T) {
+	if err := ValidateUrl("https://example.com/hook"); err != nil {
+		t.Error(err)
+	}
+	if err := ValidateUrl("ftp://example.com/hook"); err == nil {
+		t.Error("expected error")
+	}
+	if err := ValidateUrl("/hook"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestSenderRejectsInternalHosts(t *testing.T) {
+	called := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		called <- true
+	}))
+	defer server.Close()
+
+	sender, err := New(configuration.Config{CallbackMaxRetries: 3, CallbackInitialBackoff: "10ms"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, callbackUrl := range []string{server.URL, "http://localhost/hook", "http://169.254.169.254/latest/meta-data", "http://10.0.0.1/hook", "http://[::1]/hook"} {
+		err = sender.Check(context.Background(), callbackUrl)
+		if !errors.Is(err, ErrHostNotAllowed) {
+			t.Error(callbackUrl, err)
+		}
+	}
+	err = sender.send(server.URL, []byte("{}"))
+	if !errors.Is(err, ErrHostNotAllowed) {
+		t.Error(err)
+	}
+	select {
+	case <-called:
+		t.Error("internal host was called")
+	default:
+	}
+
+	sender, err = New(configuration.Config{CallbackAllowedHosts: []string{".example.com"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = sender.Check(context.Background(), "https://hooks.example.com/hook")
+	if err != nil {
+		t.Error(err)
+	}
+	err = sender.Check(context.Background(), "https://example.org/hook")
+	if !errors.Is(err, ErrHostNotAllowed) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"errors"
+	"slices"
+)
+
+const (
+	AggregationMin      = "min"
+	AggregationMax      = "max"
+	AggregationAvg      = "avg"
+	AggregationSum      = "sum"
+	AggregationMedian   = "median"
+	AggregationCount    = "count"
+	AggregationFirst    = "first"
+	AggregationLast     = "last"
+	AggregationAllEqual = "all-equal"
+)
+
+var numericAggregations = map[string]bool{
+	AggregationMin:    true,
+	AggregationMax:    true,
+	AggregationAvg:    true,
+	AggregationSum:    true,
+	AggregationMedian: true,
+}
+
+var aggregations = map[string]bool{
+	AggregationMin:      true,
+	AggregationMax:      true,
+	AggregationAvg:      true,
+	AggregationSum:      true,
+	AggregationMedian:   true,
+	AggregationCount:    true,
+	AggregationFirst:    true,
+	AggregationLast:     true,
+	AggregationAllEqual: true,
+}
+
+// AggregationResult is the response of a group command with aggregation
+type AggregationResult struct {
+	Aggregation  string       `json:"aggregation"`
+	Value        interface{}  `json:"value"`        //nil if no value contributed
+	Contributing int          `json:"contributing"` //number of sub results used for the value
+	Ignored      int          `json:"ignored"`      //successful sub results without numeric value, for numeric aggregations
+	Failed       int          `json:"failed"`       //failed sub commands
+	Details      *GroupResult `json:"details,omitempty"`
+}
+
+func (this CommandMessage) ValidateAggregation() error {
+	if this.Aggregation == "" {
+		return nil
+	}
+	if !aggregations[this.Aggregation] {
+		return errors.New("unknown aggregation " + this.Aggregation)
+	}
+	if this.GroupId == "" {
+		return errors.New("aggregation is only supported for group commands")
+	}
+	if !isMeasuringFunctionId(this.FunctionId) {
+		return errors.New("aggregation expects a measuring function")
+	}
+	return nil
+}
+
+// aggregate applies aggregation to the successful sub results in sub command order
+func aggregate(aggregation string, subResults []GroupSubResult) (result AggregationResult) {
+	result.Aggregation = aggregation
+	values := []interface{}{}
+	for _, sub := range subResults {
+		if sub.Error != nil {
+			result.Failed++
+			continue
+		}
+		values = append(values, sub.Value)
+	}
+	if numericAggregations[aggregation] {
+		numbers := []float64{}
+		for _, value := range values {
+			number, ok := toFloat(normalizeJsonValue(value))
+			if !ok {
+				result.Ignored++
+				continue
+			}
+			numbers = append(numbers, number)
+		}
+		result.Contributing = len(numbers)
+		if len(numbers) > 0 {
+			result.Value = aggregateNumbers(aggregation, numbers)
+		}
+		return result
+	}
+	result.Contributing = len(values)
+	switch aggregation {
+	case AggregationCount:
+		result.Value = len(values)
+	case AggregationFirst:
+		if len(values) > 0 {
+			result.Value = values[0]
+		}
+	case AggregationLast:
+		if len(values) > 0 {
+			result.Value = values[len(values)-1]
+		}
+	case AggregationAllEqual:
+		allEqual := true
+		for _, value := range values {
+			if !valuesMatch(values[0], value, 0) {
+				allEqual = false
+				break
+			}
+		}
+		result.Value = allEqual
+	}
+	return result
+}
+
+// expects at least one number
+func aggregateNumbers(aggregation string, numbers []float64) float64 {
+	switch aggregation {
+	case AggregationMin:
+		return slices.Min(numbers)
+	case AggregationMax:
+		return slices.Max(numbers)
+	case AggregationSum, AggregationAvg:
+		sum := 0.0
+		for _, number := range numbers {
+			sum = sum + number
+		}
+		if aggregation == AggregationAvg {
+			return sum / float64(len(numbers))
+		}
+		return sum
+	case AggregationMedian:
+		sorted := slices.Clone(numbers)
+		slices.Sort(sorted)
+		middle := len(sorted) / 2
+		if len(sorted)%2 == 0 {
+			return (sorted[middle-1] + sorted[middle]) / 2
+		}
+		return sorted[middle]
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGroupCommandAggregation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := newDeviceMock()
+	devices.set("t1", 20.0)
+	devices.set("t2", 22.0)
+	devices.set("t3", 27.0)
+	devices.set("text", "off")
+	devices.failing["broken"] = true
+	cmd := newTestCommand(t, ctx, devices, map[string][]string{
+		"group":  {"t1", "t2", "t3", "text", "broken"},
+		"failed": {"broken"},
+	}, nil)
+	token := testToken(t)
+
+	tests := []struct {
+		aggregation  string
+		value        interface{}
+		contributing int
+		ignored      int
+	}{
+		{aggregation: AggregationMin, value: 20.0, contributing: 3, ignored: 1},
+		{aggregation: AggregationMax, value: 27.0, contributing: 3, ignored: 1},
+		{aggregation: AggregationAvg, value: 23.0, contributing: 3, ignored: 1},
+		{aggregation: AggregationSum, value: 69.0, contributing: 3, ignored: 1},
+		{aggregation: AggregationMedian, value: 22.0, contributing: 3, ignored: 1},
+		{aggregation: AggregationCount, value: 4, contributing: 4},
+		{aggregation: AggregationFirst, value: 20.0, contributing: 4},
+		{aggregation: AggregationLast, value: "off", contributing: 4},
+		{aggregation: AggregationAllEqual, value: false, contributing: 4},
+	}
+	for _, test := range tests {
+		t.Run(test.aggregation, func(t *testing.T) {
+			code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testGetFunctionId, GroupId: "group", Aggregation: test.aggregation}, "5s", false)
+			result, ok := resp.(AggregationResult)
+			if code != http.StatusOK || !ok {
+				t.Errorf("%v %#v", code, resp)
+				return
+			}
+			if result.Aggregation != test.aggregation || result.Value != test.value || result.Contributing != test.contributing || result.Ignored != test.ignored || result.Failed != 1 || result.Details != nil {
+				t.Errorf("%#v", result)
+			}
+		})
+	}
+
+	t.Run("details", func(t *testing.T) {
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testGetFunctionId, GroupId: "group", Aggregation: AggregationMax, GroupResultDetails: true}, "5s", false)
+		result, ok := resp.(AggregationResult)
+		if code != http.StatusOK || !ok {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if result.Value != 27.0 || result.Details == nil || result.Details.Succeeded != 4 || result.Details.Failed != 1 {
+			t.Errorf("%#v", result)
+		}
+	})
+
+	t.Run("all failed", func(t *testing.T) {
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testGetFunctionId, GroupId: "failed", Aggregation: AggregationMax}, "5s", false)
+		commandErr, ok := resp.(*CommandError)
+		if code == http.StatusOK || !ok || commandErr.ErrorCode != ErrCodeDeviceError {
+			t.Errorf("%v %#v", code, resp)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/audit"
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/callback"
+	"github.com/SENERGY-Platform/device-command/pkg/register"
+	"github.com/SENERGY-Platform/device-command/pkg/tasks"
+)
+
+// CommandAsync starts the command in the background and returns a task which may be polled with GetTask.
+// if cmd.CallbackUrl is set, the result is additionally posted to it.
+// the task keeps the values (e.g. trace) of ctx but outlives its cancellation; it is canceled by removing the task.
+func (this *Command) CommandAsync(ctx context.Context, token auth.Token, cmd CommandMessage, timeout string, preferEventValue bool) tasks.Task {
+	task, ctx := this.tasks.CreateWithContext(context.WithoutCancel(ctx), token.GetUserId())
+	go func() {
+		code, resp := this.command(ctx, token, cmd, timeout, preferEventValue, task.Id)
+		if this.tasks.Complete(task.Id, code, resp) && cmd.CallbackUrl != "" {
+			this.callbacks.Send(cmd.CallbackUrl, callback.Message{TaskId: task.Id, StatusCode: code, Message: resp})
+		}
+	}()
+	return task
+}
+
+// BatchAsync starts the batch in the background; the task result is the list of BatchResultElement.
+// if callbackUrl is set, the list is additionally posted to it.
+func (this *Command) BatchAsync(ctx context.Context, token auth.Token, batch BatchRequest, timeout string, preferEventValue bool, callbackUrl string) tasks.Task {
+	task, ctx := this.tasks.CreateWithContext(context.WithoutCancel(ctx), token.GetUserId())
+	go func() {
+		result := this.Batch(ctx, token, batch, timeout, preferEventValue)
+		if this.tasks.Complete(task.Id, http.StatusOK, result) && callbackUrl != "" {
+			this.callbacks.Send(callbackUrl, callback.Message{TaskId: task.Id, StatusCode: http.StatusOK, Message: result})
+		}
+	}()
+	return task
+}
+
+// ValidateCallbackUrl checks if callbacks may be sent to callbackUrl
+func (this *Command) ValidateCallbackUrl(ctx context.Context, callbackUrl string) error {
+	return this.callbacks.Check(ctx, callbackUrl)
+}
+
+func (this *Command) GetTask(token auth.Token, id string) (tasks.Task, error) {
+	return this.tasks.Get(token.GetUserId(), id)
+}
+
+// DeleteTask abandons the task and cancels its running command; a result arriving later is discarded and no callback is sent
+func (this *Command) DeleteTask(token auth.Token, id string) error {
+	return this.tasks.Remove(token.GetUserId(), id)
+}
+
+// recoverPendingTasks handles async tasks of a previous run: tasks with a pending device command may still be completed
+// by a late response, all others are marked as interrupted
+func (this *Command) recoverPendingTasks() error {
+	linked, err := this.register.PendingTaskIds()
+	if err != nil {
+		return err
+	}
+	for _, task := range this.tasks.ListPending() {
+		if !linked[task.Id] {
+			this.tasks.CompleteAfterRestart(task.Id, http.StatusInternalServerError, "task interrupted by service restart")
+		}
+	}
+	return this.register.Recover(this.handleRecoveredRegisterEntry)
+}
+
+func (this *Command) handleRecoveredRegisterEntry(entry register.Entry, code int, value interface{}) {
+	if this.config.Debug {
+		log.Println("DEBUG: recovered register entry", entry.Id, entry.TaskId, code)
+	}
+	now := time.Now()
+	this.writeAudit(audit.Record{
+		Time:       now,
+		Event:      audit.EventComplete,
+		TaskId:     entry.Id,
+		UserId:     entry.UserId,
+		DeviceId:   entry.DeviceId,
+		ServiceId:  entry.ServiceId,
+		FunctionId: entry.FunctionId,
+		StatusCode: code,
+		LatencyMs:  now.Sub(entry.Created).Milliseconds(),
+	})
+	if entry.TaskId == "" {
+		return
+	}
+	if code == http.StatusOK {
+		value = []interface{}{value}
+	}
+	this.tasks.CompleteAfterRestart(entry.TaskId, code, value)
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"log"
+
+	"github.com/SENERGY-Platform/device-command/pkg/audit"
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+)
+
+func (this *Command) writeAudit(record audit.Record) {
+	if this.audit == nil {
+		return
+	}
+	err := this.audit.Write(record)
+	if err != nil {
+		log.Println("ERROR: unable to write audit record", record.TaskId, record.Event, err)
+	}
+}
+
+// QueryAudit returns the audit records of the user of token; query.UserId is ignored
+func (this *Command) QueryAudit(token auth.Token, query audit.Query) ([]audit.Record, error) {
+	if this.audit == nil {
+		return nil, audit.ErrQueryNotSupported
+	}
+	query.UserId = token.GetUserId()
+	return this.audit.Query(query)
+}
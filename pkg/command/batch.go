@@ -17,21 +17,30 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"hash/maphash"
 	"net/http"
 	"sync"
 
 	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/callback"
 	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
 )
 
-func (this *Command) Batch(token auth.Token, batch BatchRequest, timeout string, preferEventValue bool) []BatchResultElement {
+func (this *Command) Batch(ctx context.Context, token auth.Token, batch BatchRequest, timeout string, preferEventValue bool) []BatchResultElement {
+	return this.BatchWithListener(ctx, token, batch, timeout, preferEventValue, nil)
+}
+
+// BatchWithListener works like Batch but additionally calls listener for each result as soon as it is available.
+// listener calls are serialized and may be used to stream results.
+// commands are started as defined by the group_* config values; if ctx is canceled, pending commands are aborted and their result elements report the cancellation.
+// timeout applies to each command, so that commands started late by a sequential or rolling rollout get the full timeout.
+func (this *Command) BatchWithListener(ctx context.Context, token auth.Token, batch BatchRequest, timeout string, preferEventValue bool, listener func(index int, element BatchResultElement)) []BatchResultElement {
 	if len(batch) == 0 {
 		return []BatchResultElement{}
 	}
 	result := make([]BatchResultElement, len(batch))
-	wg := sync.WaitGroup{}
 	mux := sync.Mutex{}
 
 	hashSeed := maphash.MakeSeed()
@@ -41,42 +50,51 @@ func (this *Command) Batch(token auth.Token, batch BatchRequest, timeout string,
 		resultIndexMap[hash] = append(resultIndexMap[hash], i)
 	}
 	isAlreadySend := map[uint64]bool{}
-
+	uniqueCommands := []CommandMessage{}
+	uniqueResultIndexes := [][]int{}
 	for _, cmd := range batch {
 		hash := cmd.Hash(hashSeed)
 		if !isAlreadySend[hash] {
 			isAlreadySend[hash] = true
-			resultIndexesOuter := resultIndexMap[hash]
-			wg.Add(1)
-			go func(resultIndexes []int, cmd CommandMessage) {
-				defer wg.Done()
-				var code int
-				var temp interface{}
-				if cmd.DeviceId != "" && cmd.ServiceId != "" {
-					code, temp = this.DeviceCommand(token, cmd.DeviceId, cmd.ServiceId, cmd.FunctionId, cmd.AspectId, cmd.Input, timeout, preferEventValue, cmd.CharacteristicId)
-				} else if cmd.GroupId != "" {
-					code, temp = this.GroupCommand(token, cmd.GroupId, cmd.FunctionId, cmd.AspectId, cmd.DeviceClassId, cmd.Input, timeout, preferEventValue, cmd.CharacteristicId)
-				}
-				if code != http.StatusOK {
-					this.config.GetLogger().Warn("error batch response element", "user", token.GetUserId(), "code", code, "response", fmt.Sprintf("%#v", result))
-				}
-				mux.Lock()
-				defer mux.Unlock()
-				for _, index := range resultIndexes {
-					result[index] = BatchResultElement{
-						StatusCode: code,
-						Message:    temp,
-					}
-				}
-				return
-			}(resultIndexesOuter, cmd)
+			uniqueCommands = append(uniqueCommands, cmd)
+			uniqueResultIndexes = append(uniqueResultIndexes, resultIndexMap[hash])
+		}
+	}
+
+	complete := func(cmd CommandMessage, resultIndexes []int, code int, temp interface{}) {
+		mux.Lock()
+		defer mux.Unlock()
+		for _, index := range resultIndexes {
+			result[index] = NewBatchResultElement(code, temp)
+			if listener != nil {
+				listener(index, result[index])
+			}
+			if cmd.CallbackUrl != "" {
+				batchIndex := index
+				this.callbacks.Send(cmd.CallbackUrl, callback.Message{BatchIndex: &batchIndex, StatusCode: code, Message: temp})
+			}
+		}
+	}
+
+	skipped := runRollout(ctx, this.getRollout(nil), len(uniqueCommands), func(i int) bool {
+		cmd := uniqueCommands[i]
+		code, temp := this.Command(ctx, token, cmd, timeout, preferEventValue)
+		if code != http.StatusOK {
+			this.config.GetLogger().Warn("error batch response element", "user", token.GetUserId(), "code", code, "response", fmt.Sprintf("%#v", temp))
+		}
+		complete(cmd, uniqueResultIndexes[i], code, temp)
+		return code == http.StatusOK
+	})
+	for i, cmd := range uniqueCommands {
+		if skipped[i] {
+			code, err := skippedError(ctx, AffectedIds{DeviceId: cmd.DeviceId, ServiceId: cmd.ServiceId, GroupId: cmd.GroupId, FunctionId: cmd.FunctionId, AspectId: cmd.AspectId})
+			complete(cmd, uniqueResultIndexes[i], code, err)
 		}
 	}
-	wg.Wait()
 	return result
 }
 
-func (this *Command) expectedEventRequests(token auth.Token, batch []CommandMessage, preferEventValue bool) (count int64, err error) {
+func (this *Command) expectedEventRequests(ctx context.Context, token auth.Token, batch []CommandMessage, preferEventValue bool) (count int64, err error) {
 	hashSeed := maphash.MakeSeed()
 	isAlreadySend := map[uint64]bool{}
 	for _, cmd := range batch {
@@ -84,32 +102,32 @@ func (this *Command) expectedEventRequests(token auth.Token, batch []CommandMess
 		if !isAlreadySend[hash] {
 			isAlreadySend[hash] = true
 			if cmd.DeviceId != "" && cmd.ServiceId != "" {
-				device, err := this.iot.GetDevice(token.Jwt(), cmd.DeviceId)
+				device, err := this.iot.GetDevice(ctx, token.Jwt(), cmd.DeviceId)
 				if err != nil {
 					return count, err
 				}
-				service, err := this.iot.GetService(token.Jwt(), device, cmd.ServiceId)
+				service, err := this.iot.GetService(ctx, token.Jwt(), device, cmd.ServiceId)
 				if err != nil {
 					return count, err
 				}
 				var aspectError error
 				if cmd.AspectId != "" {
-					_, aspectError = this.iot.GetAspectNode(cmd.AspectId)
+					_, aspectError = this.iot.GetAspectNode(ctx, cmd.AspectId)
 				}
 				if aspectError == nil && isMeasuringFunctionId(cmd.FunctionId) && (service.Interaction == model.EVENT || (preferEventValue && service.Interaction == model.EVENT_AND_REQUEST)) {
 					count = count + 1
 				}
 			} else if cmd.GroupId != "" {
-				subTasks, err := this.GetSubTasks(token.Jwt(), cmd.GroupId, cmd.FunctionId, cmd.AspectId, cmd.DeviceClassId, cmd.Input)
+				subTasks, err := this.GetSubTasks(ctx, token.Jwt(), cmd.GroupId, cmd.FunctionId, cmd.AspectId, cmd.DeviceClassId, cmd.Input)
 				if err != nil {
 					return count, err
 				}
 				for _, sub := range subTasks {
-					device, err := this.iot.GetDevice(token.Jwt(), sub.DeviceId)
+					device, err := this.iot.GetDevice(ctx, token.Jwt(), sub.DeviceId)
 					if err != nil {
 						return count, err
 					}
-					service, err := this.iot.GetService(token.Jwt(), device, sub.ServiceId)
+					service, err := this.iot.GetService(ctx, token.Jwt(), device, sub.ServiceId)
 					if err != nil {
 						return count, err
 					}
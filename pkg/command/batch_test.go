@@ -0,0 +1,95 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+)
+
+func TestBatchTimeoutPerCommand(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := newDeviceMock()
+	devices.delay = 300 * time.Millisecond
+	cmd := newTestCommand(t, ctx, devices, nil, func(config *configuration.Config) {
+		config.GroupScheduler = RolloutSequential
+	})
+
+	batch := BatchRequest{}
+	for _, deviceId := range []string{"a", "b", "c"} {
+		batch = append(batch, CommandMessage{FunctionId: testSetFunctionId, DeviceId: deviceId, ServiceId: "service", Input: 1})
+	}
+	//the sequential batch takes longer than the timeout, but every single command is faster
+	result := cmd.Batch(ctx, testToken(t), batch, "500ms", false)
+	for i, element := range result {
+		if element.StatusCode != http.StatusOK {
+			t.Errorf("%v: %#v", i, element)
+		}
+	}
+}
+
+func TestBatchWithListenerStreamsResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := newDeviceMock()
+	devices.delays["slow"] = 300 * time.Millisecond
+	devices.failing["broken"] = true
+	cmd := newTestCommand(t, ctx, devices, nil, func(config *configuration.Config) {
+		config.GroupScheduler = RolloutParallel
+	})
+
+	batch := BatchRequest{
+		{FunctionId: testSetFunctionId, DeviceId: "slow", ServiceId: "service", Input: 1},
+		{FunctionId: testSetFunctionId, DeviceId: "fast", ServiceId: "service", Input: 1},
+		{FunctionId: testSetFunctionId, DeviceId: "broken", ServiceId: "service", Input: 1},
+		{FunctionId: testSetFunctionId, DeviceId: "fast", ServiceId: "service", Input: 1},
+	}
+	streamed := map[int]BatchResultElement{}
+	order := []int{}
+	result := cmd.BatchWithListener(ctx, testToken(t), batch, "5s", false, func(index int, element BatchResultElement) {
+		streamed[index] = element
+		order = append(order, index)
+	})
+
+	if len(order) != len(batch) {
+		t.Fatal(order)
+	}
+	//the slow command completes last; the duplicated fast command is sent once but reported for both indexes
+	if order[len(order)-1] != 0 {
+		t.Error(order)
+	}
+	if count := devices.sentTo("fast", testSetFunctionId); count != 1 {
+		t.Error(count)
+	}
+	for i, element := range result {
+		if streamed[i].StatusCode != element.StatusCode {
+			t.Errorf("%v: %#v %#v", i, streamed[i], element)
+		}
+		expected := http.StatusOK
+		if i == 2 {
+			expected = http.StatusInternalServerError
+		}
+		if element.StatusCode != expected {
+			t.Errorf("%v: %#v", i, element)
+		}
+	}
+}
@@ -18,27 +18,52 @@ package command
 
 import (
 	"context"
+	"github.com/SENERGY-Platform/device-command/pkg/audit"
 	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/callback"
 	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/impl/cloud"
 	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/impl/mgw"
 	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/interfaces"
 	"github.com/SENERGY-Platform/device-command/pkg/command/metrics"
 	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/device-command/pkg/history"
+	"github.com/SENERGY-Platform/device-command/pkg/idempotency"
+	"github.com/SENERGY-Platform/device-command/pkg/ratelimit"
 	"github.com/SENERGY-Platform/device-command/pkg/register"
+	"github.com/SENERGY-Platform/device-command/pkg/scaling"
+	"github.com/SENERGY-Platform/device-command/pkg/storage"
+	"github.com/SENERGY-Platform/device-command/pkg/tasks"
+	"github.com/SENERGY-Platform/device-command/pkg/tracing"
 	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
-	"github.com/SENERGY-Platform/external-task-worker/lib/marshaller"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 type Command struct {
 	iot        interfaces.Iot
 	timescale  interfaces.Timescale
 	register   *register.Register
+	tasks      *tasks.Store
+	scheduler  *scheduler
+	callbacks  *callback.Sender
+	router     *scaling.Router
 	config     configuration.Config
-	marshaller marshaller.Interface
+	marshaller interfaces.Marshaller
 	producer   interfaces.Producer
 	metrics    *metrics.Metrics
+
+	idempotency *idempotency.Store
+	resultCache *resultCache
+	limiter     *ratelimit.Limiter
+	audit       audit.Sink
+	history     *history.Store[DeviceHistoryEntry]
+	traces      *tracing.Correlation
+
+	healthChecks       map[string]interfaces.HealthChecker
+	healthCheckTimeout time.Duration
 }
 
 func New(ctx context.Context, config configuration.Config) (cmd *Command, err error) {
@@ -73,19 +98,77 @@ func NewWithFactories(ctx context.Context, config configuration.Config, comFacto
 		}
 	}()
 	config = ensureScalingSuffix(config)
+	taskRetention := 10 * time.Minute
+	if config.AsyncTaskRetention != "" && config.AsyncTaskRetention != "-" {
+		taskRetention, err = time.ParseDuration(config.AsyncTaskRetention)
+		if err != nil {
+			return nil, err
+		}
+	}
 	cmd = &Command{
-		config:   config,
-		register: register.New(config.DefaultTimeoutDuration, config.Debug),
-		metrics:  metrics.New(),
+		config:  config,
+		metrics: metrics.New(config.MetricsHighCardinalityLabels),
+		traces:  tracing.NewCorrelation(),
+	}
+	cmd.healthCheckTimeout = 5 * time.Second
+	if config.HealthCheckTimeout != "" && config.HealthCheckTimeout != "-" {
+		cmd.healthCheckTimeout, err = time.ParseDuration(config.HealthCheckTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.IdempotencyWindow != "" && config.IdempotencyWindow != "-" {
+		idempotencyWindow, err := time.ParseDuration(config.IdempotencyWindow)
+		if err != nil {
+			return nil, err
+		}
+		cmd.idempotency = idempotency.New(ctx, idempotencyWindow)
+	}
+	if config.ResultCacheMaxAge != "" && config.ResultCacheMaxAge != "-" {
+		resultCacheMaxAge, err := time.ParseDuration(config.ResultCacheMaxAge)
+		if err != nil {
+			return nil, err
+		}
+		cmd.resultCache = newResultCache(ctx, resultCacheMaxAge)
+	}
+	cmd.limiter = ratelimit.New(ctx, map[string]ratelimit.Limit{
+		RateLimitScopeUser:          {Rate: config.RateLimitUser, Burst: config.RateLimitUserBurst},
+		RateLimitScopeDevice:        {Rate: config.RateLimitDevice, Burst: config.RateLimitDeviceBurst},
+		RateLimitScopeDeviceService: {Rate: config.RateLimitDeviceService, Burst: config.RateLimitDeviceServiceBurst},
+	})
+	cmd.audit, err = audit.New(ctx, config)
+	if err != nil {
+		return cmd, err
 	}
-	cmd.iot, err = iotFactory(ctx, config)
+	cmd.history, err = newDeviceHistory(ctx, config)
 	if err != nil {
 		return cmd, err
 	}
-	cmd.timescale, err = timescaleFactory(ctx, config)
+	cmd.register, cmd.tasks, err = newRegisterAndTaskStore(ctx, config, taskRetention)
 	if err != nil {
 		return cmd, err
 	}
+	cmd.metrics.SetPendingRegisterEntriesSource(cmd.register.Pending)
+	cmd.callbacks, err = callback.New(config)
+	if err != nil {
+		return cmd, err
+	}
+	cmd.router, err = scaling.New(ctx, config)
+	if err != nil {
+		return cmd, err
+	}
+	iot, err := iotFactory(ctx, config)
+	if err != nil {
+		return cmd, err
+	}
+	cmd.addHealthCheck("iot", iot)
+	cmd.iot = instrumentedIot{iot: iot, metrics: cmd.metrics}
+	timescale, err := timescaleFactory(ctx, config)
+	if err != nil {
+		return cmd, err
+	}
+	cmd.addHealthCheck("timescale", timescale)
+	cmd.timescale = instrumentedTimescale{timescale: timescale, metrics: cmd.metrics}
 	cmd.marshaller, err = marshallerFactory(ctx, config, cmd.iot)
 	if err != nil {
 		return cmd, err
@@ -94,10 +177,72 @@ func NewWithFactories(ctx context.Context, config configuration.Config, comFacto
 	if err != nil {
 		return cmd, err
 	}
+	cmd.addHealthCheck("com", cmd.producer)
+	err = cmd.recoverPendingTasks()
+	if err != nil {
+		return cmd, err
+	}
+	cmd.scheduler, err = newScheduler(config)
+	if err != nil {
+		return cmd, err
+	}
+	cmd.startScheduler(ctx)
 	return cmd, nil
 }
 
+func newRegisterAndTaskStore(ctx context.Context, config configuration.Config, taskRetention time.Duration) (*register.Register, *tasks.Store, error) {
+	switch config.RegisterBackend {
+	case "bolt":
+		return newBoltRegisterAndTaskStore(ctx, config, taskRetention)
+	case "file":
+		return newFileRegisterAndTaskStore(ctx, config, taskRetention)
+	default:
+		return register.New(config.DefaultTimeoutDuration, config.Debug), tasks.New(ctx, taskRetention), nil
+	}
+}
+
+// newFileRegisterAndTaskStore keeps each register entry and task as json file in register_backend_dir
+func newFileRegisterAndTaskStore(ctx context.Context, config configuration.Config, taskRetention time.Duration) (*register.Register, *tasks.Store, error) {
+	registerBackend, err := storage.NewFile[register.Entry](filepath.Join(config.RegisterBackendDir, "register"))
+	if err != nil {
+		return nil, nil, err
+	}
+	taskStore, err := tasks.NewPersistent(ctx, taskRetention, filepath.Join(config.RegisterBackendDir, "tasks"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return register.NewWithBackend(config.DefaultTimeoutDuration, config.Debug, registerBackend), taskStore, nil
+}
+
+// newBoltRegisterAndTaskStore keeps register entries and tasks in one embedded database in register_backend_dir
+func newBoltRegisterAndTaskStore(ctx context.Context, config configuration.Config, taskRetention time.Duration) (*register.Register, *tasks.Store, error) {
+	err := os.MkdirAll(config.RegisterBackendDir, 0700)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := storage.OpenBolt(filepath.Join(config.RegisterBackendDir, "register.db"))
+	if err != nil {
+		return nil, nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		db.Close()
+	}()
+	registerBackend, err := storage.NewBolt[register.Entry](db, "register")
+	if err != nil {
+		return nil, nil, err
+	}
+	taskStore, err := tasks.NewBolt(ctx, taskRetention, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	return register.NewWithBackend(config.DefaultTimeoutDuration, config.Debug, registerBackend), taskStore, nil
+}
+
 func ensureScalingSuffix(config configuration.Config) configuration.Config {
+	if config.ScalingMode == scaling.ModeShared {
+		return config
+	}
 	config.MetadataErrorTo = config.MetadataErrorTo + config.TopicSuffixForScaling
 	config.MetadataResponseTo = config.MetadataResponseTo + config.TopicSuffixForScaling
 	config.ErrorTopic = config.ErrorTopic + config.TopicSuffixForScaling
@@ -112,14 +257,43 @@ func isMeasuringFunctionId(id string) bool {
 	return false
 }
 
-func (this *Command) Command(token auth.Token, cmd CommandMessage, timeout string, preferEventValue bool) (code int, resp interface{}) {
+// Command executes cmd; stage timings are recorded if ctx was created by WithStageTimings
+func (this *Command) Command(ctx context.Context, token auth.Token, cmd CommandMessage, timeout string, preferEventValue bool) (code int, resp interface{}) {
+	return this.command(ctx, token, cmd, timeout, preferEventValue, "")
+}
+
+// asyncTaskId links device commands to the async task waiting for the result.
+// timeout is applied once as deadline of the whole command, including condition, return_previous and verify stages.
+func (this *Command) command(ctx context.Context, token auth.Token, cmd CommandMessage, timeout string, preferEventValue bool, asyncTaskId string) (code int, resp interface{}) {
+	ctx, cancel := this.withTimeout(ctx, timeout)
+	defer cancel()
+	if cmd.MaxAge != "" {
+		maxAge, err := time.ParseDuration(cmd.MaxAge)
+		if err != nil {
+			return http.StatusBadRequest, NewCommandError(http.StatusBadRequest, ErrCodeInvalidRequest, "invalid max_age: "+err.Error(), AffectedIds{DeviceId: cmd.DeviceId, ServiceId: cmd.ServiceId, GroupId: cmd.GroupId, FunctionId: cmd.FunctionId, AspectId: cmd.AspectId})
+		}
+		ctx = withMaxAge(ctx, maxAge)
+	}
+	if cmd.Condition != nil {
+		return this.conditionalCommand(ctx, token, cmd, timeout, preferEventValue, asyncTaskId)
+	}
+	if cmd.ReturnPrevious && cmd.DeviceId != "" && cmd.ServiceId != "" {
+		return this.commandWithPrevious(ctx, token, cmd, timeout, preferEventValue, asyncTaskId)
+	}
+	if cmd.Verify != nil && cmd.DeviceId != "" && cmd.ServiceId != "" {
+		return this.verifiedCommand(ctx, token, cmd, timeout, preferEventValue, asyncTaskId)
+	}
 	if cmd.DeviceId != "" && cmd.ServiceId != "" {
-		return this.DeviceCommand(token, cmd.DeviceId, cmd.ServiceId, cmd.FunctionId, cmd.AspectId, cmd.Input, timeout, preferEventValue, cmd.CharacteristicId)
+		code, resp = this.deviceCommand(ctx, token, cmd.DeviceId, cmd.ServiceId, cmd.FunctionId, cmd.AspectId, cmd.Input, timeout, preferEventValue, cmd.CharacteristicId, asyncTaskId)
+		if code == http.StatusOK {
+			resp = []interface{}{resp}
+		}
+		return code, resp
 	}
 	if cmd.GroupId != "" {
-		return this.GroupCommand(token, cmd.GroupId, cmd.FunctionId, cmd.AspectId, cmd.DeviceClassId, cmd.Input, timeout, preferEventValue, cmd.CharacteristicId)
+		return this.groupCommand(ctx, token, cmd, timeout, preferEventValue)
 	}
-	return http.StatusBadRequest, "missing device_id, service_id or group_id"
+	return http.StatusBadRequest, NewCommandError(http.StatusBadRequest, ErrCodeInvalidRequest, "missing device_id, service_id or group_id", AffectedIds{FunctionId: cmd.FunctionId})
 }
 
 func (this *Command) GetMetricsHttpHandler() *metrics.Metrics {
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
+)
+
+// Condition references a measured value, which is read as last event value, and compares it to Value
+type Condition struct {
+	DeviceId         string      `json:"device_id"`
+	ServiceId        string      `json:"service_id"`
+	FunctionId       string      `json:"function_id"`         //measuring function
+	AspectId         string      `json:"aspect_id,omitempty"` //optional
+	CharacteristicId string      `json:"characteristic_id,omitempty"`
+	Operator         string      `json:"operator"` //"<" || "<=" || "==" || "!=" || ">=" || ">"
+	Value            interface{} `json:"value"`
+}
+
+// ConditionalResult is the response of a command with condition
+type ConditionalResult struct {
+	Skipped        bool        `json:"skipped"`
+	ConditionValue interface{} `json:"condition_value"`
+	Result         interface{} `json:"result,omitempty"` //response of the command, if it was not skipped; failed commands return their error instead of a ConditionalResult
+}
+
+var conditionOperators = map[string]bool{"<": true, "<=": true, "==": true, "!=": true, ">=": true, ">": true}
+
+func (this Condition) Validate() error {
+	if this.DeviceId == "" || this.ServiceId == "" {
+		return errors.New("expect device_id and service_id in condition")
+	}
+	if !isMeasuringFunctionId(this.FunctionId) {
+		return errors.New("expect measuring function_id in condition")
+	}
+	if !conditionOperators[this.Operator] {
+		return errors.New("unknown condition operator " + this.Operator)
+	}
+	if this.Value == nil {
+		return errors.New("expect value in condition")
+	}
+	if this.Operator != "==" && this.Operator != "!=" {
+		if _, ok := toFloat(this.Value); !ok {
+			return errors.New("condition operator " + this.Operator + " expects numeric value")
+		}
+	}
+	return nil
+}
+
+func (this *Command) conditionalCommand(ctx context.Context, token auth.Token, cmd CommandMessage, timeout string, preferEventValue bool, asyncTaskId string) (code int, resp interface{}) {
+	condition := *cmd.Condition
+	cmd.Condition = nil
+	ids := AffectedIds{DeviceId: condition.DeviceId, ServiceId: condition.ServiceId, FunctionId: condition.FunctionId, AspectId: condition.AspectId}
+	code, value := this.getConditionValue(ctx, token, condition, timeout)
+	if code != http.StatusOK {
+		if commandErr, ok := value.(*CommandError); ok {
+			commandErr.Detail = "unable to evaluate condition: " + commandErr.Detail
+			return code, commandErr
+		}
+		return code, NewCommandError(code, ErrCodeConditionEvaluation, "unable to evaluate condition: "+errorDetail(value), ids)
+	}
+	holds, err := evaluateCondition(condition, value)
+	if err != nil {
+		return http.StatusInternalServerError, NewCommandError(http.StatusInternalServerError, ErrCodeConditionEvaluation, "unable to evaluate condition: "+err.Error(), ids)
+	}
+	if !holds {
+		return http.StatusOK, ConditionalResult{Skipped: true, ConditionValue: value}
+	}
+	code, resp = this.command(ctx, token, cmd, timeout, preferEventValue, asyncTaskId)
+	if code != http.StatusOK {
+		return code, resp //keep the *CommandError visible for problem responses and BatchResultElement.Error
+	}
+	return code, ConditionalResult{Skipped: false, ConditionValue: value, Result: resp}
+}
+
+func (this *Command) getConditionValue(ctx context.Context, token auth.Token, condition Condition, timeout string) (code int, result interface{}) {
+	ctx, cancel := this.withTimeout(ctx, timeout)
+	defer cancel()
+	ids := AffectedIds{DeviceId: condition.DeviceId, ServiceId: condition.ServiceId, FunctionId: condition.FunctionId, AspectId: condition.AspectId}
+	start := time.Now()
+	device, err := this.iot.GetDevice(ctx, token.Jwt(), condition.DeviceId)
+	if err != nil {
+		return loadError(ctx, ErrCodeDeviceLoad, "unable to load device: "+err.Error(), ids)
+	}
+	service, err := this.iot.GetService(ctx, token.Jwt(), device, condition.ServiceId)
+	if err != nil {
+		return loadError(ctx, ErrCodeServiceLoad, "unable to load service: "+err.Error(), ids)
+	}
+	ids.ProtocolId = service.ProtocolId
+	characteristicId := condition.CharacteristicId
+	if characteristicId == "" {
+		function, err := this.iot.GetFunction(ctx, condition.FunctionId)
+		if err != nil {
+			return loadError(ctx, ErrCodeFunctionLoad, "unable to load function: "+err.Error(), ids)
+		}
+		if function.ConceptId != "" {
+			ids.ConceptId = function.ConceptId
+			concept, err := this.iot.GetConcept(ctx, function.ConceptId)
+			if err != nil {
+				return loadError(ctx, ErrCodeConceptLoad, "unable to load concept: "+err.Error(), ids)
+			}
+			characteristicId = concept.BaseCharacteristicId
+		}
+	}
+	protocol, err := this.iot.GetProtocol(ctx, token.Jwt(), service.ProtocolId)
+	if err != nil {
+		return loadError(ctx, ErrCodeProtocolLoad, "unable to load protocol: "+err.Error(), ids)
+	}
+	aspect := model.AspectNode{}
+	if condition.AspectId != "" {
+		aspect, err = this.iot.GetAspectNode(ctx, condition.AspectId)
+		if err != nil {
+			return loadError(ctx, ErrCodeAspectLoad, "unable to load aspect node: "+err.Error(), ids)
+		}
+	}
+	recordStage(ctx, StageLoadMetadata, start)
+	this.metrics.LogGetLastEventValue(token.GetUserId(), device.Id, service.Id, condition.FunctionId)
+	return this.GetLastEventValue(ctx, token, device, service, protocol, characteristicId, condition.FunctionId, aspect)
+}
+
+func evaluateCondition(condition Condition, value interface{}) (bool, error) {
+	actual, actualIsNumber := toFloat(value)
+	expected, expectedIsNumber := toFloat(condition.Value)
+	if actualIsNumber && expectedIsNumber {
+		switch condition.Operator {
+		case "<":
+			return actual < expected, nil
+		case "<=":
+			return actual <= expected, nil
+		case "==":
+			return actual == expected, nil
+		case "!=":
+			return actual != expected, nil
+		case ">=":
+			return actual >= expected, nil
+		case ">":
+			return actual > expected, nil
+		}
+	}
+	switch condition.Operator {
+	case "==":
+		return reflect.DeepEqual(value, condition.Value), nil
+	case "!=":
+		return !reflect.DeepEqual(value, condition.Value), nil
+	}
+	return false, fmt.Errorf("unable to compare %#v %v %#v", value, condition.Operator, condition.Value)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	}
+	return 0, false
+}
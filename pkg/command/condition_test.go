@@ -0,0 +1,82 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestConditionalCommand(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := newDeviceMock()
+	devices.set("thermometer", 18.0)
+	devices.set("heater", 0.0)
+	cmd := newTestCommand(t, ctx, devices, nil, nil)
+	token := testToken(t)
+
+	condition := func(operator string, value interface{}) *Condition {
+		return &Condition{DeviceId: "thermometer", ServiceId: "service", FunctionId: testGetFunctionId, Operator: operator, Value: value}
+	}
+
+	t.Run("met", func(t *testing.T) {
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, DeviceId: "heater", ServiceId: "service", Input: 21, Condition: condition("<", 19)}, "5s", false)
+		result, ok := resp.(ConditionalResult)
+		if code != http.StatusOK || !ok {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if result.Skipped || result.ConditionValue != 18.0 {
+			t.Errorf("%#v", result)
+		}
+		if value := devices.get("heater"); value != 21.0 {
+			t.Error(value)
+		}
+	})
+
+	t.Run("not met", func(t *testing.T) {
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, DeviceId: "heater", ServiceId: "service", Input: 30, Condition: condition(">=", 19)}, "5s", false)
+		result, ok := resp.(ConditionalResult)
+		if code != http.StatusOK || !ok {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if !result.Skipped || result.ConditionValue != 18.0 || result.Result != nil {
+			t.Errorf("%#v", result)
+		}
+		if value := devices.get("heater"); value != 21.0 {
+			t.Error(value)
+		}
+		if count := devices.sentTo("heater", testSetFunctionId); count != 1 {
+			t.Error(count)
+		}
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		missing := condition("<", 19)
+		missing.DeviceId = "unknown"
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, DeviceId: "heater", ServiceId: "service", Input: 30, Condition: missing}, "5s", false)
+		if code == http.StatusOK {
+			t.Errorf("%v %#v", code, resp)
+		}
+		if value := devices.get("heater"); value != 21.0 {
+			t.Error(value)
+		}
+	})
+}
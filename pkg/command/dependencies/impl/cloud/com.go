@@ -21,14 +21,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/IBM/sarama"
 	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/interfaces"
 	"github.com/SENERGY-Platform/device-command/pkg/configuration"
-	"github.com/SENERGY-Platform/external-task-worker/lib/com"
+	"github.com/SENERGY-Platform/device-command/pkg/tracing"
 	"github.com/SENERGY-Platform/external-task-worker/lib/com/kafka"
 	"github.com/SENERGY-Platform/external-task-worker/lib/messages"
 	"github.com/SENERGY-Platform/external-task-worker/util"
 	"log"
 	"runtime/debug"
+	"sync"
+	"time"
 )
 
 func ComFactory(ctx context.Context, config configuration.Config, responseListener func(msg messages.ProtocolMsg) error, errorListener func(msg messages.ProtocolMsg) error) (producer interfaces.Producer, err error) {
@@ -44,24 +47,152 @@ func ComFactory(ctx context.Context, config configuration.Config, responseListen
 	if err != nil {
 		return producer, err
 	}
-	prod := &Producer{}
-	prod.libProducer, err = comFactory.NewProducer(ctx, libConfig)
+	return NewProducer(ctx, config)
+}
+
+// Producer sends commands like the external-task-worker producer, which is not able to set message headers.
+// the trace context of a command is sent in the traceparent and tracestate headers.
+// commands are sent with a sarama.SyncProducer in both modes, so that produce errors reach the caller:
+// sync mode sends every message with acks of all replicas (idempotent if sync_idempotent is set),
+// async mode batches messages by async_flush_frequency and async_flush_messages.
+type Producer struct {
+	producer sarama.SyncProducer
+	admin    sarama.ClusterAdmin
+	config   configuration.Config
+	topics   sync.Map //topic -> *topicInit
+}
+
+type topicInit struct {
+	mux     sync.Mutex
+	created bool
+}
+
+func NewProducer(ctx context.Context, config configuration.Config) (result *Producer, err error) {
+	saramaConfig, err := getSaramaConfig(config)
 	if err != nil {
-		return prod, err
+		return nil, err
+	}
+	result = &Producer{config: config}
+	if config.InitTopics {
+		result.admin, err = sarama.NewClusterAdmin([]string{config.KafkaUrl}, saramaConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.producer, err = sarama.NewSyncProducer([]string{config.KafkaUrl}, saramaConfig)
+	if err != nil {
+		if result.admin != nil {
+			result.admin.Close()
+		}
+		return nil, err
 	}
-	return prod, nil
+	go func() {
+		<-ctx.Done()
+		err := result.producer.Close()
+		if err != nil {
+			log.Println("ERROR: unable to close kafka producer", err)
+		}
+		if result.admin != nil {
+			result.admin.Close()
+		}
+	}()
+	return result, nil
 }
 
-type Producer struct {
-	libProducer com.ProducerInterface
+func getSaramaConfig(config configuration.Config) (result *sarama.Config, err error) {
+	result = sarama.NewConfig()
+	result.Version = sarama.V2_2_0_0
+	result.Producer.Return.Successes = true
+	result.Producer.Return.Errors = true
+	result.Producer.Partitioner = sarama.NewHashPartitioner
+	if config.Sync {
+		result.Producer.Compression, err = getCompression(config.SyncCompression)
+		if err != nil {
+			return nil, err
+		}
+		result.Producer.RequiredAcks = sarama.WaitForAll
+		result.Producer.Flush.MaxMessages = 1
+		if config.SyncIdempotent {
+			result.Producer.Idempotent = true
+			result.Net.MaxOpenRequests = 1
+		}
+		return result, nil
+	}
+	result.Producer.Compression, err = getCompression(config.AsyncCompression)
+	if err != nil {
+		return nil, err
+	}
+	result.Producer.RequiredAcks = sarama.WaitForLocal
+	if config.AsyncFlushFrequency != "" {
+		result.Producer.Flush.Frequency, err = time.ParseDuration(config.AsyncFlushFrequency)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.AsyncFlushMessages > 0 {
+		result.Producer.Flush.Messages = int(config.AsyncFlushMessages)
+	}
+	return result, nil
 }
 
-func (this *Producer) SendCommand(msg messages.ProtocolMsg) (err error) {
+func getCompression(name string) (result sarama.CompressionCodec, err error) {
+	if name == "" || name == "-" {
+		return sarama.CompressionNone, nil
+	}
+	err = result.UnmarshalText([]byte(name))
+	return result, err
+}
+
+func (this *Producer) SendCommand(ctx context.Context, msg messages.ProtocolMsg) (err error) {
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
 	message, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
-	return this.libProducer.ProduceWithKey(msg.Metadata.Protocol.Handler, msg.Metadata.Device.Id, string(message))
+	topic := msg.Metadata.Protocol.Handler
+	err = this.ensureTopic(topic)
+	if err != nil {
+		return err
+	}
+	_, _, err = this.producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.StringEncoder(msg.Metadata.Device.Id),
+		Value:   sarama.ByteEncoder(message),
+		Headers: tracing.KafkaHeaders(ctx),
+	})
+	return err
+}
+
+// ensureTopic creates topic once, if init_topics is set; existing topics are not changed.
+// only the first messages of a topic wait for its creation, failed creations are retried with the next message
+func (this *Producer) ensureTopic(topic string) error {
+	if this.admin == nil {
+		return nil
+	}
+	value, _ := this.topics.LoadOrStore(topic, &topicInit{})
+	state := value.(*topicInit)
+	state.mux.Lock()
+	defer state.mux.Unlock()
+	if state.created {
+		return nil
+	}
+	detail := &sarama.TopicDetail{
+		NumPartitions:     int32(this.config.PartitionNum),
+		ReplicationFactor: int16(this.config.ReplicationFactor),
+		ConfigEntries:     map[string]*string{},
+	}
+	for _, entry := range this.config.KafkaTopicConfigs[topic] {
+		detail.ConfigEntries[entry.ConfigName] = &entry.ConfigValue
+	}
+	err := this.admin.CreateTopic(topic, detail, false)
+	if err != nil && !errors.Is(err, sarama.ErrTopicAlreadyExists) {
+		return err
+	}
+	state.created = true
+	return nil
 }
 
 func getLibListener(listener func(msg messages.ProtocolMsg) error) (result func(msg string) error) {
@@ -104,6 +235,15 @@ func getQueuedResponseHandler(ctx context.Context, workerCount int64, queueSize
 	}
 }
 
+// libGroupScheduler translates group_scheduler to a value known by the external-task-worker lib;
+// "rolling" starts waves of parallel commands and is passed as "parallel"
+func libGroupScheduler(scheduler string) string {
+	if scheduler == "rolling" {
+		return "parallel"
+	}
+	return scheduler
+}
+
 func createLibConfig(config configuration.Config) util.Config {
 	return util.Config{
 		Debug:                           config.Debug,
@@ -115,7 +255,7 @@ func createLibConfig(config configuration.Config) util.Config {
 		KafkaConsumerGroup:              config.KafkaConsumerGroup,
 		ResponseTopic:                   config.ResponseTopic,
 		MarshallerUrl:                   config.MarshallerUrl,
-		GroupScheduler:                  config.GroupScheduler,
+		GroupScheduler:                  libGroupScheduler(config.GroupScheduler),
 		MetadataResponseTo:              config.MetadataResponseTo,
 		AsyncFlushFrequency:             config.AsyncFlushFrequency,
 		AsyncCompression:                config.AsyncCompression,
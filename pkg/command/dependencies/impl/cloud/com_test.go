@@ -0,0 +1,131 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloud
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/device-command/pkg/tracing"
+	"github.com/SENERGY-Platform/external-task-worker/lib/messages"
+)
+
+func TestSaramaConfig(t *testing.T) {
+	t.Run("sync idempotent", func(t *testing.T) {
+		config, err := getSaramaConfig(configuration.Config{Sync: true, SyncIdempotent: true, SyncCompression: "snappy"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !config.Producer.Idempotent || config.Net.MaxOpenRequests != 1 || config.Producer.RequiredAcks != sarama.WaitForAll || config.Producer.Compression != sarama.CompressionSnappy {
+			t.Errorf("%#v", config.Producer)
+		}
+		if err = config.Validate(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("async", func(t *testing.T) {
+		config, err := getSaramaConfig(configuration.Config{AsyncFlushFrequency: "500ms", AsyncFlushMessages: 200, AsyncCompression: "-"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if config.Producer.Idempotent || config.Producer.Flush.Frequency != 500*time.Millisecond || config.Producer.Flush.Messages != 200 || config.Producer.Compression != sarama.CompressionNone {
+			t.Errorf("%#v", config.Producer)
+		}
+		if err = config.Validate(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("unknown compression", func(t *testing.T) {
+		_, err := getSaramaConfig(configuration.Config{AsyncCompression: "foo"})
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestSendCommandReturnsProduceError(t *testing.T) {
+	for _, config := range []configuration.Config{{Sync: true, SyncIdempotent: true}, {AsyncFlushFrequency: "10ms"}} {
+		saramaConfig, err := getSaramaConfig(config)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		mock := mocks.NewSyncProducer(t, saramaConfig)
+		mock.ExpectSendMessageAndFail(sarama.ErrNotEnoughReplicas)
+		producer := &Producer{producer: mock, config: config}
+		msg := messages.ProtocolMsg{}
+		msg.Metadata.Protocol.Handler = "connector"
+		err = producer.SendCommand(context.Background(), msg)
+		if !errors.Is(err, sarama.ErrNotEnoughReplicas) {
+			t.Error(config.Sync, err)
+		}
+		mock.Close()
+	}
+}
+
+func TestSendCommandSetsTraceHeaders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := tracing.Init(ctx, configuration.Config{TracingExporter: "file", TracingFile: filepath.Join(t.TempDir(), "spans.json"), TracingServiceName: "test"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	spanCtx, span := tracing.Start(ctx, "produce")
+	defer span.End()
+
+	mock := mocks.NewSyncProducer(t, nil)
+	mock.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(message *sarama.ProducerMessage) error {
+		if message.Topic != "connector" {
+			return errors.New("unexpected topic " + message.Topic)
+		}
+		if key, _ := message.Key.Encode(); string(key) != "device" {
+			return errors.New("unexpected key " + string(key))
+		}
+		for _, header := range message.Headers {
+			if string(header.Key) == "traceparent" {
+				return nil
+			}
+		}
+		return errors.New("missing traceparent header")
+	})
+	defer mock.Close()
+	producer := &Producer{producer: mock}
+	msg := messages.ProtocolMsg{}
+	msg.Metadata.Protocol.Handler = "connector"
+	msg.Metadata.Device.Id = "device"
+	err = producer.SendCommand(spanCtx, msg)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLibGroupScheduler(t *testing.T) {
+	for scheduler, expected := range map[string]string{"rolling": "parallel", "parallel": "parallel", "sequential": "sequential", "": ""} {
+		if actual := createLibConfig(configuration.Config{GroupScheduler: scheduler}).GroupScheduler; actual != expected {
+			t.Error(scheduler, actual)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloud
+
+import "context"
+
+// withContext returns ctx.Err() as soon as ctx is done, for calls of clients without context support.
+// the call itself is not canceled; its result is discarded
+func withContext[T any](ctx context.Context, call func() (T, error)) (result T, err error) {
+	err = ctx.Err()
+	if err != nil {
+		return result, err
+	}
+	type callResult struct {
+		value T
+		err   error
+	}
+	done := make(chan callResult, 1)
+	go func() {
+		value, err := call()
+		done <- callResult{value: value, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		return result, ctx.Err()
+	case r := <-done:
+		return r.value, r.err
+	}
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloud
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// HealthCheck checks if the kafka brokers are reachable
+func (this *Producer) HealthCheck(ctx context.Context) error {
+	conn, err := kafka.DialContext(ctx, "tcp", this.config.KafkaUrl)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		err = conn.SetDeadline(deadline)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = conn.Brokers()
+	return err
+}
+
+// HealthCheck checks if the device repository is reachable
+func (this *Iot) HealthCheck(ctx context.Context) error {
+	return CheckHttpReachable(ctx, this.config.DeviceRepositoryUrl)
+}
+
+// HealthCheck checks if the timescale wrapper is reachable
+func (this *Timescale) HealthCheck(ctx context.Context) error {
+	return CheckHttpReachable(ctx, this.TimescaleWrapperUrl)
+}
+
+// CheckHttpReachable sends a GET request to url; every response below 500 counts as reachable
+func CheckHttpReachable(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 500 {
+		return errors.New("unexpected status code " + strconv.Itoa(resp.StatusCode))
+	}
+	return nil
+}
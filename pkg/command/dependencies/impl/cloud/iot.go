@@ -100,13 +100,15 @@ func NewIotWithDeviceRepoClient(config configuration.Config, cache *cache.Cache,
 	return &Iot{config: config, cache: cache, cacheDevices: cacheDevices, cacheExpiration: cacheExpiration, client: client, overwriteAuthTokens: overwriteAuthTokens, auth: &auth.OpenidToken{}}
 }
 
-func (this *Iot) GetFunction(id string) (result model.Function, err error) {
+func (this *Iot) GetFunction(ctx context.Context, id string) (result model.Function, err error) {
 	use := cache.Use[model.Function]
+	loadCtx := ctx
 	if this.config.AsyncCacheRefresh {
 		use = cache.UseWithAsyncRefresh[model.Function]
+		loadCtx = context.WithoutCancel(ctx) //cache.UseWithAsyncRefresh loads in the background; the load may outlive the request
 	}
 	return use(this.cache, "function."+id, func() (model.Function, error) {
-		return this.getFunction(id)
+		return this.getFunction(loadCtx, id)
 	}, func(function model.Function) error {
 		if function.Id == "" {
 			return errors.New("invalid function loaded from cache")
@@ -115,18 +117,22 @@ func (this *Iot) GetFunction(id string) (result model.Function, err error) {
 	}, this.cacheExpiration)
 }
 
-func (this *Iot) getFunction(id string) (result model.Function, err error) {
-	result, err, _ = this.client.GetFunction(id)
-	return
+func (this *Iot) getFunction(ctx context.Context, id string) (result model.Function, err error) {
+	return withContext(ctx, func() (model.Function, error) {
+		result, err, _ := this.client.GetFunction(id)
+		return result, err
+	})
 }
 
-func (this *Iot) GetConcept(id string) (result model.Concept, err error) {
+func (this *Iot) GetConcept(ctx context.Context, id string) (result model.Concept, err error) {
 	use := cache.Use[model.Concept]
+	loadCtx := ctx
 	if this.config.AsyncCacheRefresh {
 		use = cache.UseWithAsyncRefresh[model.Concept]
+		loadCtx = context.WithoutCancel(ctx)
 	}
 	return use(this.cache, "concept."+id, func() (model.Concept, error) {
-		return this.getConcept(id)
+		return this.getConcept(loadCtx, id)
 	}, func(concept model.Concept) error {
 		if concept.Id == "" {
 			return errors.New("invalid concept loaded from cache")
@@ -135,19 +141,23 @@ func (this *Iot) GetConcept(id string) (result model.Concept, err error) {
 	}, this.cacheExpiration)
 }
 
-func (this *Iot) getConcept(id string) (result model.Concept, err error) {
-	result, err, _ = this.client.GetConceptWithoutCharacteristics(id)
-	return
+func (this *Iot) getConcept(ctx context.Context, id string) (result model.Concept, err error) {
+	return withContext(ctx, func() (model.Concept, error) {
+		result, err, _ := this.client.GetConceptWithoutCharacteristics(id)
+		return result, err
+	})
 }
 
-func (this *Iot) GetDevice(token string, id string) (result model.Device, err error) {
+func (this *Iot) GetDevice(ctx context.Context, token string, id string) (result model.Device, err error) {
 	if this.cacheDevices {
 		use := cache.Use[model.Device]
+		loadCtx := ctx
 		if this.config.AsyncCacheRefresh {
 			use = cache.UseWithAsyncRefresh[model.Device]
+			loadCtx = context.WithoutCancel(ctx)
 		}
 		return use(this.cache, "device."+id, func() (model.Device, error) {
-			return this.getDevice(token, id)
+			return this.getDevice(loadCtx, token, id)
 		}, func(device model.Device) error {
 			if device.Id == "" {
 				return errors.New("invalid device loaded from cache")
@@ -155,27 +165,29 @@ func (this *Iot) GetDevice(token string, id string) (result model.Device, err er
 			return nil
 		}, this.cacheExpiration)
 	}
-	return this.getDevice(token, id)
+	return this.getDevice(ctx, token, id)
 }
 
-func (this *Iot) getDevice(token string, id string) (result model.Device, err error) {
+func (this *Iot) getDevice(ctx context.Context, token string, id string) (result model.Device, err error) {
 	if this.overwriteAuthTokens {
 		token, err = this.auth.EnsureAccess(this.config)
 		if err != nil {
 			return model.Device{}, err
 		}
 	}
-	err = this.GetJson(token, this.config.DeviceRepositoryUrl+"/devices/"+url.QueryEscape(id), &result)
+	err = this.GetJson(ctx, token, this.config.DeviceRepositoryUrl+"/devices/"+url.QueryEscape(id), &result)
 	return
 }
 
-func (this *Iot) GetProtocol(token string, id string) (result model.Protocol, err error) {
+func (this *Iot) GetProtocol(ctx context.Context, token string, id string) (result model.Protocol, err error) {
 	use := cache.Use[model.Protocol]
+	loadCtx := ctx
 	if this.config.AsyncCacheRefresh {
 		use = cache.UseWithAsyncRefresh[model.Protocol]
+		loadCtx = context.WithoutCancel(ctx)
 	}
 	return use(this.cache, "protocol."+id, func() (model.Protocol, error) {
-		return this.getProtocol(token, id)
+		return this.getProtocol(loadCtx, token, id)
 	}, func(protocol model.Protocol) error {
 		if protocol.Id == "" {
 			return errors.New("invalid protocol loaded from cache")
@@ -184,21 +196,21 @@ func (this *Iot) GetProtocol(token string, id string) (result model.Protocol, er
 	}, this.cacheExpiration)
 }
 
-func (this *Iot) getProtocol(token string, id string) (result model.Protocol, err error) {
+func (this *Iot) getProtocol(ctx context.Context, token string, id string) (result model.Protocol, err error) {
 	if this.overwriteAuthTokens {
 		token, err = this.auth.EnsureAccess(this.config)
 		if err != nil {
 			return model.Protocol{}, err
 		}
 	}
-	err = this.GetJson(token, this.config.DeviceRepositoryUrl+"/protocols/"+url.QueryEscape(id), &result)
+	err = this.GetJson(ctx, token, this.config.DeviceRepositoryUrl+"/protocols/"+url.QueryEscape(id), &result)
 	return
 }
 
-func (this *Iot) GetService(token string, device model.Device, id string) (result model.Service, err error) {
+func (this *Iot) GetService(ctx context.Context, token string, device model.Device, id string) (result model.Service, err error) {
 	result, err = this.getServiceFromCache(id)
 	if err != nil {
-		dt, err := this.GetDeviceType(token, device.DeviceTypeId)
+		dt, err := this.GetDeviceType(ctx, token, device.DeviceTypeId)
 		if err != nil {
 			log.Println("ERROR: unable to load device-type", device.DeviceTypeId)
 			return result, err
@@ -229,13 +241,15 @@ func (this *Iot) saveServiceToCache(service model.Service) {
 	_ = this.cache.Set("service."+service.Id, service, this.cacheExpiration)
 }
 
-func (this *Iot) GetDeviceType(token string, id string) (result model.DeviceType, err error) {
+func (this *Iot) GetDeviceType(ctx context.Context, token string, id string) (result model.DeviceType, err error) {
 	use := cache.Use[model.DeviceType]
+	loadCtx := ctx
 	if this.config.AsyncCacheRefresh {
 		use = cache.UseWithAsyncRefresh[model.DeviceType]
+		loadCtx = context.WithoutCancel(ctx)
 	}
 	return use(this.cache, "device-type."+id, func() (model.DeviceType, error) {
-		return this.getDeviceType(token, id)
+		return this.getDeviceType(loadCtx, token, id)
 	}, func(deviceType model.DeviceType) error {
 		if deviceType.Id == "" {
 			return errors.New("invalid device-type loaded from cache")
@@ -244,24 +258,26 @@ func (this *Iot) GetDeviceType(token string, id string) (result model.DeviceType
 	}, this.cacheExpiration)
 }
 
-func (this *Iot) getDeviceType(token string, id string) (result model.DeviceType, err error) {
+func (this *Iot) getDeviceType(ctx context.Context, token string, id string) (result model.DeviceType, err error) {
 	if this.overwriteAuthTokens {
 		token, err = this.auth.EnsureAccess(this.config)
 		if err != nil {
 			return model.DeviceType{}, err
 		}
 	}
-	err = this.GetJson(token, this.config.DeviceRepositoryUrl+"/device-types/"+url.QueryEscape(id), &result)
+	err = this.GetJson(ctx, token, this.config.DeviceRepositoryUrl+"/device-types/"+url.QueryEscape(id), &result)
 	return
 }
 
-func (this *Iot) GetDeviceGroup(token string, id string) (result model.DeviceGroup, err error) {
+func (this *Iot) GetDeviceGroup(ctx context.Context, token string, id string) (result model.DeviceGroup, err error) {
 	use := cache.Use[model.DeviceGroup]
+	loadCtx := ctx
 	if this.config.AsyncCacheRefresh {
 		use = cache.UseWithAsyncRefresh[model.DeviceGroup]
+		loadCtx = context.WithoutCancel(ctx)
 	}
 	return use(this.cache, "device-group."+id, func() (model.DeviceGroup, error) {
-		return this.getDeviceGroup(token, id)
+		return this.getDeviceGroup(loadCtx, token, id)
 	}, func(group model.DeviceGroup) error {
 		if group.Id == "" {
 			return errors.New("invalid device-group loaded from cache")
@@ -270,20 +286,20 @@ func (this *Iot) GetDeviceGroup(token string, id string) (result model.DeviceGro
 	}, this.cacheExpiration)
 }
 
-func (this *Iot) getDeviceGroup(token string, id string) (result model.DeviceGroup, err error) {
+func (this *Iot) getDeviceGroup(ctx context.Context, token string, id string) (result model.DeviceGroup, err error) {
 	if this.overwriteAuthTokens {
 		token, err = this.auth.EnsureAccess(this.config)
 		if err != nil {
 			return model.DeviceGroup{}, err
 		}
 	}
-	err = this.GetJson(token, this.config.DeviceRepositoryUrl+"/device-groups/"+url.QueryEscape(id), &result)
+	err = this.GetJson(ctx, token, this.config.DeviceRepositoryUrl+"/device-groups/"+url.QueryEscape(id), &result)
 	return
 }
 
-func (this *Iot) GetJson(token string, endpoint string, result interface{}) (err error) {
+func (this *Iot) GetJson(ctx context.Context, token string, endpoint string, result interface{}) (err error) {
 	this.lastUsedToken = token
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return err
 	}
@@ -310,13 +326,15 @@ func (this *Iot) GetJson(token string, endpoint string, result interface{}) (err
 	return
 }
 
-func (this *Iot) GetAspectNode(id string) (result model.AspectNode, err error) {
+func (this *Iot) GetAspectNode(ctx context.Context, id string) (result model.AspectNode, err error) {
 	use := cache.Use[model.AspectNode]
+	loadCtx := ctx
 	if this.config.AsyncCacheRefresh {
 		use = cache.UseWithAsyncRefresh[model.AspectNode]
+		loadCtx = context.WithoutCancel(ctx)
 	}
 	return use(this.cache, "aspect-nodes."+id, func() (model.AspectNode, error) {
-		return this.getAspectNode(id)
+		return this.getAspectNode(loadCtx, id)
 	}, func(node model.AspectNode) error {
 		if node.Id == "" {
 			return errors.New("invalid aspect-node loaded from cache")
@@ -325,34 +343,41 @@ func (this *Iot) GetAspectNode(id string) (result model.AspectNode, err error) {
 	}, this.cacheExpiration)
 }
 
-func (this *Iot) getAspectNode(id string) (result model.AspectNode, err error) {
-	result, err, _ = this.client.GetAspectNode(id)
-	return
+func (this *Iot) getAspectNode(ctx context.Context, id string) (result model.AspectNode, err error) {
+	return withContext(ctx, func() (model.AspectNode, error) {
+		result, err, _ := this.client.GetAspectNode(id)
+		return result, err
+	})
 }
 
 type IdWrapper struct {
 	Id string `json:"id"`
 }
 
-func (this *Iot) GetConceptIds() (ids []string, err error) {
+func (this *Iot) GetConceptIds(ctx context.Context) (ids []string, err error) {
 	use := cache.Use[[]string]
+	loadCtx := ctx
 	if this.config.AsyncCacheRefresh {
 		use = cache.UseWithAsyncRefresh[[]string]
+		loadCtx = context.WithoutCancel(ctx)
 	}
 	return use(this.cache, "concept_ids", func() ([]string, error) {
-		return this.getConceptIds()
+		return this.getConceptIds(loadCtx)
 	}, cache.NoValidation[[]string], this.cacheExpiration)
 }
 
-func (this *Iot) getConceptIds() (ids []string, err error) {
+func (this *Iot) getConceptIds(ctx context.Context) (ids []string, err error) {
 	limit := 1000
 	offset := 0
 	temp := []model.Concept{}
 	for len(temp) == limit || offset == 0 {
-		temp, _, err, _ = this.client.ListConcepts(client.ConceptListOptions{
-			Limit:  int64(limit),
-			Offset: int64(offset),
-			SortBy: "name.asc",
+		temp, err = withContext(ctx, func() ([]model.Concept, error) {
+			result, _, err, _ := this.client.ListConcepts(client.ConceptListOptions{
+				Limit:  int64(limit),
+				Offset: int64(offset),
+				SortBy: "name.asc",
+			})
+			return result, err
 		})
 		if err != nil {
 			return ids, err
@@ -365,25 +390,30 @@ func (this *Iot) getConceptIds() (ids []string, err error) {
 	return ids, err
 }
 
-func (this *Iot) ListFunctions() (functionInfos []model.Function, err error) {
+func (this *Iot) ListFunctions(ctx context.Context) (functionInfos []model.Function, err error) {
 	use := cache.Use[[]model.Function]
+	loadCtx := ctx
 	if this.config.AsyncCacheRefresh {
 		use = cache.UseWithAsyncRefresh[[]model.Function]
+		loadCtx = context.WithoutCancel(ctx)
 	}
 	return use(this.cache, "functions", func() ([]model.Function, error) {
-		return this.listFunctions()
+		return this.listFunctions(loadCtx)
 	}, cache.NoValidation[[]model.Function], this.cacheExpiration)
 }
 
-func (this *Iot) listFunctions() (functionInfos []model.Function, err error) {
+func (this *Iot) listFunctions(ctx context.Context) (functionInfos []model.Function, err error) {
 	limit := 1000
 	offset := 0
 	temp := []model.Function{}
 	for len(temp) == limit || offset == 0 {
-		temp, _, err, _ = this.client.ListFunctions(client.FunctionListOptions{
-			Limit:  int64(limit),
-			Offset: int64(offset),
-			SortBy: "name.asc",
+		temp, err = withContext(ctx, func() ([]model.Function, error) {
+			result, _, err, _ := this.client.ListFunctions(client.FunctionListOptions{
+				Limit:  int64(limit),
+				Offset: int64(offset),
+				SortBy: "name.asc",
+			})
+			return result, err
 		})
 		if err != nil {
 			return functionInfos, err
@@ -394,13 +424,15 @@ func (this *Iot) listFunctions() (functionInfos []model.Function, err error) {
 	return functionInfos, err
 }
 
-func (this *Iot) GetCharacteristic(id string) (result model.Characteristic, err error) {
+func (this *Iot) GetCharacteristic(ctx context.Context, id string) (result model.Characteristic, err error) {
 	use := cache.Use[model.Characteristic]
+	loadCtx := ctx
 	if this.config.AsyncCacheRefresh {
 		use = cache.UseWithAsyncRefresh[model.Characteristic]
+		loadCtx = context.WithoutCancel(ctx)
 	}
 	return use(this.cache, "characteristics."+id, func() (model.Characteristic, error) {
-		return this.getCharacteristic(id)
+		return this.getCharacteristic(loadCtx, id)
 	}, func(characteristic model.Characteristic) error {
 		if characteristic.Id == "" {
 			return errors.New("invalid characteristic loaded from cache")
@@ -409,7 +441,9 @@ func (this *Iot) GetCharacteristic(id string) (result model.Characteristic, err
 	}, this.cacheExpiration)
 }
 
-func (this *Iot) getCharacteristic(id string) (result model.Characteristic, err error) {
-	result, err, _ = this.client.GetCharacteristic(id)
-	return
+func (this *Iot) getCharacteristic(ctx context.Context, id string) (result model.Characteristic, err error) {
+	return withContext(ctx, func() (model.Characteristic, error) {
+		result, err, _ := this.client.GetCharacteristic(id)
+		return result, err
+	})
 }
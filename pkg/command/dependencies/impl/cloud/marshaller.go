@@ -20,9 +20,39 @@ import (
 	"context"
 	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/interfaces"
 	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
 	"github.com/SENERGY-Platform/external-task-worker/lib/marshaller"
 )
 
 func MarshallerFactory(ctx context.Context, config configuration.Config, iot interfaces.Iot) (interfaces.Marshaller, error) {
-	return marshaller.New(config.MarshallerUrl), nil
+	return &Marshaller{client: marshaller.New(config.MarshallerUrl)}, nil
+}
+
+// Marshaller applies the context of each call to the marshaller client
+type Marshaller struct {
+	client marshaller.Interface
+}
+
+func (this *Marshaller) MarshalFromServiceAndProtocol(ctx context.Context, characteristicId string, service model.Service, protocol model.Protocol, characteristicData interface{}, configurables []marshaller.Configurable) (result map[string]string, err error) {
+	return withContext(ctx, func() (map[string]string, error) {
+		return this.client.MarshalFromServiceAndProtocol(characteristicId, service, protocol, characteristicData, configurables)
+	})
+}
+
+func (this *Marshaller) UnmarshalFromServiceAndProtocol(ctx context.Context, characteristicId string, service model.Service, protocol model.Protocol, message map[string]string, hints []string) (characteristicData interface{}, err error) {
+	return withContext(ctx, func() (interface{}, error) {
+		return this.client.UnmarshalFromServiceAndProtocol(characteristicId, service, protocol, message, hints)
+	})
+}
+
+func (this *Marshaller) MarshalV2(ctx context.Context, service model.Service, protocol model.Protocol, data []marshaller.MarshallingV2RequestData) (result map[string]string, err error) {
+	return withContext(ctx, func() (map[string]string, error) {
+		return this.client.MarshalV2(service, protocol, data)
+	})
+}
+
+func (this *Marshaller) UnmarshalV2(ctx context.Context, request marshaller.UnmarshallingV2Request) (characteristicData interface{}, err error) {
+	return withContext(ctx, func() (interface{}, error) {
+		return this.client.UnmarshalV2(request)
+	})
 }
@@ -29,7 +29,6 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 )
 
 type Timescale struct {
@@ -49,19 +48,16 @@ type LastMessageResponse struct {
 	Value map[string]interface{} `json:"value"`
 }
 
-func (this *Timescale) GetLastMessage(token auth.Token, device models.Device, service models.Service, protocol model.Protocol, timeout time.Duration) (result map[string]interface{}, err error) {
+func (this *Timescale) GetLastMessage(ctx context.Context, token auth.Token, device models.Device, service models.Service, protocol model.Protocol) (result map[string]interface{}, err error) {
 	query := url.Values{}
 	query.Set("device_id", device.Id)
 	query.Set("service_id", service.Id)
-	req, err := http.NewRequest("GET", this.TimescaleWrapperUrl+"/last-message?"+query.Encode(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", this.TimescaleWrapperUrl+"/last-message?"+query.Encode(), nil)
 	if err != nil {
 		return result, err
 	}
 	req.Header.Set("Authorization", token.Jwt())
-	client := &http.Client{
-		Timeout: timeout,
-	}
-	resp, err := client.Do(req)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return result, err
 	}
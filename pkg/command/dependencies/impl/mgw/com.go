@@ -22,6 +22,7 @@ import (
 	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/impl/mgw/mqtt"
 	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/interfaces"
 	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/device-command/pkg/tracing"
 	"github.com/SENERGY-Platform/external-task-worker/lib/messages"
 	"github.com/google/uuid"
 	"log"
@@ -105,8 +106,12 @@ type ComImpl struct {
 	correlation Correlation
 }
 
-func (this *ComImpl) SendCommand(msg messages.ProtocolMsg) (err error) {
-	topic, payload, err := this.convertCommandMessage(msg)
+func (this *ComImpl) SendCommand(ctx context.Context, msg messages.ProtocolMsg) (err error) {
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+	topic, payload, err := this.convertCommandMessage(ctx, msg)
 	if err != nil {
 		return err
 	}
@@ -141,7 +146,7 @@ func (this *ComImpl) getCorrelatedTask(id string) (messages.ProtocolMsg, error)
 	return this.correlation.Get(id)
 }
 
-func (this *ComImpl) convertCommandMessage(source messages.ProtocolMsg) (mqttTopic string, mqttMessage []byte, err error) {
+func (this *ComImpl) convertCommandMessage(ctx context.Context, source messages.ProtocolMsg) (mqttTopic string, mqttMessage []byte, err error) {
 	if source.Request.Input == nil {
 		source.Request.Input = map[string]string{}
 	}
@@ -158,6 +163,7 @@ func (this *ComImpl) convertCommandMessage(source messages.ProtocolMsg) (mqttTop
 	target := Command{
 		CommandId: correlationId,
 		Data:      data,
+		Trace:     tracing.Inject(ctx),
 	}
 	mqttMessage, err = json.Marshal(target)
 
@@ -165,8 +171,9 @@ func (this *ComImpl) convertCommandMessage(source messages.ProtocolMsg) (mqttTop
 }
 
 type Command struct {
-	CommandId string `json:"command_id"`
-	Data      string `json:"data"`
+	CommandId string            `json:"command_id"`
+	Data      string            `json:"data"`
+	Trace     map[string]string `json:"trace,omitempty"` //trace context (e.g. traceparent) of the command
 }
 
 var IdProvider = DefaultIdProviderImpl
@@ -0,0 +1,33 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mgw
+
+import (
+	"context"
+
+	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/impl/cloud"
+)
+
+// HealthCheck checks if the connection to the mqtt broker is open
+func (this *ComImpl) HealthCheck(ctx context.Context) error {
+	return this.client.HealthCheck(ctx)
+}
+
+// HealthCheck checks if the timescale service is reachable
+func (this *Timescale) HealthCheck(ctx context.Context) error {
+	return cloud.CheckHttpReachable(ctx, this.TimescaleWrapperUrl)
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mgw
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTimescaleHealthCheck(t *testing.T) {
+	status := atomic.Int32{}
+	status.Store(http.StatusNotFound)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	obj, err := NewTimescale(server.URL, "data")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = obj.HealthCheck(context.Background())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	status.Store(http.StatusBadGateway)
+	err = obj.HealthCheck(context.Background())
+	if err == nil {
+		t.Error("expected error for status 502")
+		return
+	}
+
+	server.Close()
+	err = obj.HealthCheck(context.Background())
+	if err == nil {
+		t.Error("expected error for closed server")
+	}
+}
@@ -260,7 +260,7 @@ func (this *ConceptRepo) register(concept model.Concept, characteristics []model
 }
 
 func (this *ConceptRepo) loadConceptIds() (ids []string, err error) {
-	return this.iot.GetConceptIds()
+	return this.iot.GetConceptIds(context.Background())
 }
 
 type FunctionInfo struct {
@@ -269,7 +269,7 @@ type FunctionInfo struct {
 }
 
 func (this *ConceptRepo) loadFunctions() (functionInfos []FunctionInfo, err error) {
-	temp, err := this.iot.ListFunctions()
+	temp, err := this.iot.ListFunctions(context.Background())
 	for _, element := range temp {
 		functionInfos = append(functionInfos, FunctionInfo{
 			Id:        element.Id,
@@ -280,7 +280,7 @@ func (this *ConceptRepo) loadFunctions() (functionInfos []FunctionInfo, err erro
 }
 
 func (this *ConceptRepo) loadConcept(id string) (result model.Concept, err error) {
-	concept, err := this.iot.GetConcept(id)
+	concept, err := this.iot.GetConcept(context.Background(), id)
 	if err != nil {
 		return result, err
 	}
@@ -289,7 +289,7 @@ func (this *ConceptRepo) loadConcept(id string) (result model.Concept, err error
 }
 
 func (this *ConceptRepo) loadCharacteristic(id string) (result model.Characteristic, err error) {
-	characteristic, err := this.iot.GetCharacteristic(id)
+	characteristic, err := this.iot.GetCharacteristic(context.Background(), id)
 	if err != nil {
 		return result, err
 	}
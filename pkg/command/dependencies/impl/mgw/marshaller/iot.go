@@ -38,7 +38,7 @@ type MarshallerIot struct {
 }
 
 func (this *MarshallerIot) GetAspectNode(id string) (result model.AspectNode, err error) {
-	temp, err := this.iot.GetAspectNode(id)
+	temp, err := this.iot.GetAspectNode(context.Background(), id)
 	if err != nil {
 		return result, err
 	}
@@ -63,7 +63,7 @@ type Marshaller struct {
 	v2         *marshaller_service_v2.Marshaller
 }
 
-func (this *Marshaller) MarshalFromServiceAndProtocol(characteristicId string, service model.Service, protocol model.Protocol, characteristicData interface{}, configurables []marshaller.Configurable) (result map[string]string, err error) {
+func (this *Marshaller) MarshalFromServiceAndProtocol(ctx context.Context, characteristicId string, service model.Service, protocol model.Protocol, characteristicData interface{}, configurables []marshaller.Configurable) (result map[string]string, err error) {
 	mockService := marshaller_service_model.Service{}
 	mockProtocol := marshaller_service_model.Protocol{}
 	mockConfigurables := []marshaller_service_configurables.Configurable{}
@@ -82,7 +82,7 @@ func (this *Marshaller) MarshalFromServiceAndProtocol(characteristicId string, s
 	return this.marshaller.MarshalInputs(mockProtocol, mockService, characteristicData, characteristicId, nil, mockConfigurables...)
 }
 
-func (this *Marshaller) UnmarshalFromServiceAndProtocol(characteristicId string, service model.Service, protocol model.Protocol, message map[string]string, hints []string) (characteristicData interface{}, err error) {
+func (this *Marshaller) UnmarshalFromServiceAndProtocol(ctx context.Context, characteristicId string, service model.Service, protocol model.Protocol, message map[string]string, hints []string) (characteristicData interface{}, err error) {
 	mockService := marshaller_service_model.Service{}
 	mockProtocol := marshaller_service_model.Protocol{}
 	err = jsonCast(service, &mockService)
@@ -110,7 +110,7 @@ func jsonCast(in interface{}, out interface{}) (err error) {
 	return nil
 }
 
-func (this *Marshaller) MarshalV2(service model.Service, protocol model.Protocol, data []marshaller.MarshallingV2RequestData) (result map[string]string, err error) {
+func (this *Marshaller) MarshalV2(ctx context.Context, service model.Service, protocol model.Protocol, data []marshaller.MarshallingV2RequestData) (result map[string]string, err error) {
 	mockService := marshaller_service_model.Service{}
 	mockProtocol := marshaller_service_model.Protocol{}
 	mockData := []marshaller_service_model.MarshallingV2RequestData{}
@@ -129,7 +129,7 @@ func (this *Marshaller) MarshalV2(service model.Service, protocol model.Protocol
 	return this.v2.Marshal(mockProtocol, mockService, mockData)
 }
 
-func (this *Marshaller) UnmarshalV2(request marshaller.UnmarshallingV2Request) (result interface{}, err error) {
+func (this *Marshaller) UnmarshalV2(ctx context.Context, request marshaller.UnmarshallingV2Request) (result interface{}, err error) {
 	mockProtocol := marshaller_service_model.Protocol{}
 	err = jsonCast(request.Protocol, &mockProtocol)
 	if err != nil {
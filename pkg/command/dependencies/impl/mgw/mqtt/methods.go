@@ -17,10 +17,14 @@
 package mqtt
 
 import (
+	"context"
+	"errors"
 	paho "github.com/eclipse/paho.mqtt.golang"
 	"log"
 )
 
+var ErrNotConnected = errors.New("connection to mqtt broker lost")
+
 func (this *Mqtt) Subscribe(topic string, qos byte, handler func(topic string, payload []byte)) error {
 	f := func(client paho.Client, message paho.Message) {
 		handler(message.Topic(), message.Payload())
@@ -52,3 +56,11 @@ func (this *Mqtt) Publish(topic string, qos byte, retained bool, payload []byte)
 	}
 	return nil
 }
+
+// HealthCheck returns ErrNotConnected while the client is disconnected or reconnecting
+func (this *Mqtt) HealthCheck(ctx context.Context) error {
+	if !this.mqtt.IsConnectionOpen() {
+		return ErrNotConnected
+	}
+	return nil
+}
@@ -31,7 +31,6 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 )
 
 type Timescale struct {
@@ -57,12 +56,12 @@ func NewTimescale(timescaleUrl string, protocolSegmentName string) (*Timescale,
 	return &Timescale{TimescaleWrapperUrl: timescaleUrl, ProtocolSegmentName: protocolSegmentName}, nil
 }
 
-func (this *Timescale) GetLastMessage(token auth.Token, device models.Device, service models.Service, protocol model.Protocol, timeout time.Duration) (result map[string]interface{}, err error) {
-	list, err := this.Query(token, []Request{{
+func (this *Timescale) GetLastMessage(ctx context.Context, token auth.Token, device models.Device, service models.Service, protocol model.Protocol) (result map[string]interface{}, err error) {
+	list, err := this.Query(ctx, token, []Request{{
 		DeviceId:   device.LocalId,
 		ServiceId:  service.LocalId,
 		ColumnName: "",
-	}}, timeout)
+	}})
 	if err != nil {
 		return result, err
 	}
@@ -91,21 +90,18 @@ func (this *Timescale) GetLastMessage(token auth.Token, device models.Device, se
 	return result, nil
 }
 
-func (this *Timescale) Query(token auth.Token, request []Request, timeout time.Duration) (result []Response, err error) {
+func (this *Timescale) Query(ctx context.Context, token auth.Token, request []Request) (result []Response, err error) {
 	body := &bytes.Buffer{}
 	err = json.NewEncoder(body).Encode(request)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", this.TimescaleWrapperUrl+"/last-values", body)
+	req, err := http.NewRequestWithContext(ctx, "POST", this.TimescaleWrapperUrl+"/last-values", body)
 	if err != nil {
 		return result, err
 	}
 	req.Header.Set("Authorization", token.Jwt())
-	client := &http.Client{
-		Timeout: timeout,
-	}
-	resp, err := client.Do(req)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Println("ERROR: unable to query /last-values", err)
 		return result, err
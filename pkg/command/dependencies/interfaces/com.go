@@ -23,7 +23,7 @@ import (
 )
 
 type Producer interface {
-	SendCommand(msg messages.ProtocolMsg) (err error)
+	SendCommand(ctx context.Context, msg messages.ProtocolMsg) (err error)
 }
 
 type ComFactory func(ctx context.Context, config configuration.Config, responseListener func(msg messages.ProtocolMsg) error, errorListener func(msg messages.ProtocolMsg) error) (producer Producer, err error)
@@ -23,18 +23,19 @@ import (
 	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
 )
 
+// Iot loads metadata from the device repository; calls return ctx.Err() if ctx is done before the result is available
 type Iot interface {
-	GetDevice(token string, id string) (result model.Device, err error)
-	GetDeviceGroup(token string, id string) (result model.DeviceGroup, err error)
-	GetService(token string, device model.Device, id string) (result model.Service, err error)
-	GetDeviceType(token string, id string) (result model.DeviceType, err error)
-	GetProtocol(token string, id string) (result model.Protocol, err error)
-	ListFunctions() (functionInfos []model.Function, err error)
-	GetFunction(id string) (result model.Function, err error)
-	GetConcept(id string) (result model.Concept, err error)
-	GetCharacteristic(id string) (result model.Characteristic, err error)
-	GetAspectNode(id string) (model.AspectNode, error)
-	GetConceptIds() ([]string, error)
+	GetDevice(ctx context.Context, token string, id string) (result model.Device, err error)
+	GetDeviceGroup(ctx context.Context, token string, id string) (result model.DeviceGroup, err error)
+	GetService(ctx context.Context, token string, device model.Device, id string) (result model.Service, err error)
+	GetDeviceType(ctx context.Context, token string, id string) (result model.DeviceType, err error)
+	GetProtocol(ctx context.Context, token string, id string) (result model.Protocol, err error)
+	ListFunctions(ctx context.Context) (functionInfos []model.Function, err error)
+	GetFunction(ctx context.Context, id string) (result model.Function, err error)
+	GetConcept(ctx context.Context, id string) (result model.Concept, err error)
+	GetCharacteristic(ctx context.Context, id string) (result model.Characteristic, err error)
+	GetAspectNode(ctx context.Context, id string) (model.AspectNode, error)
+	GetConceptIds(ctx context.Context) ([]string, error)
 }
 
 type IotFactory func(ctx context.Context, config configuration.Config) (Iot, error)
@@ -24,11 +24,11 @@ import (
 )
 
 type Marshaller interface {
-	MarshalFromServiceAndProtocol(characteristicId string, service model.Service, protocol model.Protocol, characteristicData interface{}, configurables []marshaller.Configurable) (result map[string]string, err error)
-	UnmarshalFromServiceAndProtocol(characteristicId string, service model.Service, protocol model.Protocol, message map[string]string, hints []string) (characteristicData interface{}, err error)
+	MarshalFromServiceAndProtocol(ctx context.Context, characteristicId string, service model.Service, protocol model.Protocol, characteristicData interface{}, configurables []marshaller.Configurable) (result map[string]string, err error)
+	UnmarshalFromServiceAndProtocol(ctx context.Context, characteristicId string, service model.Service, protocol model.Protocol, message map[string]string, hints []string) (characteristicData interface{}, err error)
 
-	MarshalV2(service model.Service, protocol model.Protocol, data []marshaller.MarshallingV2RequestData) (result map[string]string, err error)
-	UnmarshalV2(request marshaller.UnmarshallingV2Request) (characteristicData interface{}, err error)
+	MarshalV2(ctx context.Context, service model.Service, protocol model.Protocol, data []marshaller.MarshallingV2RequestData) (result map[string]string, err error)
+	UnmarshalV2(ctx context.Context, request marshaller.UnmarshallingV2Request) (characteristicData interface{}, err error)
 }
 
 type MarshallerFactory func(ctx context.Context, config configuration.Config, iot Iot) (Marshaller, error)
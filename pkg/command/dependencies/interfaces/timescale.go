@@ -23,11 +23,10 @@ import (
 	"github.com/SENERGY-Platform/device-command/pkg/configuration"
 	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
 	"github.com/SENERGY-Platform/models/go/models"
-	"time"
 )
 
 type Timescale interface {
-	GetLastMessage(token auth.Token, device models.Device, service models.Service, protocol model.Protocol, timeout time.Duration) (result map[string]interface{}, err error)
+	GetLastMessage(ctx context.Context, token auth.Token, device models.Device, service models.Service, protocol model.Protocol) (result map[string]interface{}, err error)
 }
 type TimescaleFactory func(ctx context.Context, config configuration.Config) (Timescale, error)
 
@@ -17,83 +17,239 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/SENERGY-Platform/device-command/pkg/audit"
 	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/register"
+	"github.com/SENERGY-Platform/device-command/pkg/status"
+	"github.com/SENERGY-Platform/device-command/pkg/tracing"
 	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
 	"github.com/SENERGY-Platform/external-task-worker/lib/marshaller"
 	"github.com/SENERGY-Platform/external-task-worker/lib/messages"
 	"github.com/SENERGY-Platform/external-task-worker/util"
-	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func (this *Command) DeviceCommand(token auth.Token, deviceId string, serviceId string, functionId string, aspectId string, input interface{}, timeout string, preferEventValue bool, characteristicId string) (code int, resp interface{}) {
-	code, resp = this.deviceCommand(token, deviceId, serviceId, functionId, aspectId, input, timeout, preferEventValue, characteristicId)
+func (this *Command) DeviceCommand(ctx context.Context, token auth.Token, deviceId string, serviceId string, functionId string, aspectId string, input interface{}, timeout string, preferEventValue bool, characteristicId string) (code int, resp interface{}) {
+	code, resp = this.deviceCommand(ctx, token, deviceId, serviceId, functionId, aspectId, input, timeout, preferEventValue, characteristicId, "")
 	if code == http.StatusOK {
 		resp = []interface{}{resp}
 	}
 	return code, resp
 }
 
-func (this *Command) deviceCommand(token auth.Token, deviceId string, serviceId string, functionId string, aspectId string, input interface{}, timeout string, preferEventValue bool, characteristicId string) (code int, resp interface{}) {
-	timeoutDuration := this.config.DefaultTimeoutDuration
-	var err error
-	if timeout != "" {
-		timeoutDuration, err = time.ParseDuration(timeout)
-		if err != nil {
-			timeoutDuration = this.config.DefaultTimeoutDuration
-		}
+// deviceCommand applies timeout as deadline to all stages of the command, including metadata loading and marshalling,
+// unless the command is part of a request which already applied its deadline
+func (this *Command) deviceCommand(ctx context.Context, token auth.Token, deviceId string, serviceId string, functionId string, aspectId string, input interface{}, timeout string, preferEventValue bool, characteristicId string, asyncTaskId string) (code int, resp interface{}) {
+	ctx, cancel := this.withTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "device_command", attribute.String("device_id", deviceId), attribute.String("service_id", serviceId), attribute.String("function_id", functionId))
+	start := time.Now()
+	defer func() {
+		tracing.EndWithStatusCode(span, code)
+		this.metrics.LogCommandResult(token.GetUserId(), deviceId, commandOutcome(code, resp), time.Since(start))
+	}()
+
+	if maxAge := getMaxAge(ctx); maxAge > 0 && this.resultCache != nil && isMeasuringFunctionId(functionId) {
+		return this.cachedDeviceCommand(ctx, maxAge, token, deviceId, serviceId, functionId, aspectId, preferEventValue, characteristicId, asyncTaskId)
+	}
+	return this.runDeviceCommand(ctx, token, deviceId, serviceId, functionId, aspectId, input, preferEventValue, characteristicId, asyncTaskId)
+}
+
+func (this *Command) runDeviceCommand(ctx context.Context, token auth.Token, deviceId string, serviceId string, functionId string, aspectId string, input interface{}, preferEventValue bool, characteristicId string, asyncTaskId string) (code int, resp interface{}) {
+	resolved, code, commandErr := this.resolveDeviceCommand(ctx, token, deviceId, serviceId, functionId, aspectId, input, preferEventValue, characteristicId)
+	if commandErr != nil {
+		return code, commandErr
+	}
+	ids := resolved.ids
+	device := resolved.message.Metadata.Device
+	service := resolved.message.Metadata.Service
+
+	if resolved.useEventValue {
+		this.metrics.LogGetLastEventValue(token.GetUserId(), device.Id, service.Id, functionId)
+		return this.GetLastEventValue(ctx, token, device, service, resolved.message.Metadata.Protocol, resolved.characteristicId, functionId, resolved.aspect)
 	}
 
-	device, err := this.iot.GetDevice(token.Jwt(), deviceId)
+	code, commandErr = this.checkRateLimit(token, device.Id, service.Id, ids)
+	if commandErr != nil {
+		return code, commandErr
+	}
+
+	taskId := this.router.NewTaskId()
+	this.register.RegisterForTask(register.Entry{
+		Id:         taskId,
+		TaskId:     asyncTaskId,
+		Timeout:    remainingTime(ctx),
+		UserId:     token.GetUserId(),
+		DeviceId:   device.Id,
+		ServiceId:  service.Id,
+		FunctionId: functionId,
+	})
+
+	protocolMessage := resolved.message
+	protocolMessage.TaskInfo.TaskId = taskId
+
+	this.metrics.LogCommandSend(token.GetUserId(), device.Id, service.Id, functionId)
+
+	sendTime := time.Now()
+	auditRecord := audit.Record{
+		Time:             sendTime,
+		Event:            audit.EventSend,
+		TaskId:           taskId,
+		UserId:           token.GetUserId(),
+		DeviceId:         device.Id,
+		ServiceId:        service.Id,
+		FunctionId:       functionId,
+		AspectId:         aspectId,
+		CharacteristicId: resolved.characteristicId,
+		Input:            input,
+	}
+	this.writeAudit(auditRecord)
+
+	produceCtx, produceSpan := tracing.Start(ctx, "produce", attribute.String("task_id", taskId))
+	this.traces.Remember(produceCtx, taskId)
+	defer this.traces.Forget(taskId)
+	err := this.producer.SendCommand(produceCtx, protocolMessage)
+	tracing.End(produceSpan, err)
+	recordStage(ctx, StageProduce, sendTime)
 	if err != nil {
-		return http.StatusInternalServerError, "unable to load device: " + err.Error()
+		log.Println("ERROR:", err)
+		this.register.Remove(taskId)
+		code, resp = loadError(ctx, ErrCodeProduce, "unable to produce message", ids)
+	} else {
+		start := time.Now()
+		_, waitSpan := tracing.Start(ctx, "wait_for_response", attribute.String("task_id", taskId))
+		code, resp = this.register.WaitWithContext(ctx, taskId)
+		tracing.EndWithStatusCode(waitSpan, code)
+		recordStage(ctx, StageWaitForResponse, start)
+		this.metrics.ObserveRegisterWait(time.Since(start))
 	}
-	service, err := this.iot.GetService(token.Jwt(), device, serviceId)
+
+	auditRecord.Time = time.Now()
+	auditRecord.Event = audit.EventComplete
+	auditRecord.Input = nil
+	auditRecord.StatusCode = code
+	auditRecord.LatencyMs = auditRecord.Time.Sub(sendTime).Milliseconds()
+	this.writeAudit(auditRecord)
+
+	resp = completeResponseError(code, resp, ids)
+	this.addDeviceHistory(device.Id, DeviceHistoryEntry{
+		Time:   sendTime,
+		UserId: token.GetUserId(),
+		Command: CommandMessage{
+			FunctionId:       functionId,
+			AspectId:         aspectId,
+			Input:            input,
+			DeviceId:         device.Id,
+			ServiceId:        service.Id,
+			CharacteristicId: characteristicId,
+		},
+		Result: NewBatchResultElement(code, resp),
+	})
+	return code, resp
+}
+
+// commandOutcome returns "ok" for successful commands and the error code for failed commands
+func commandOutcome(code int, resp interface{}) string {
+	if code == http.StatusOK {
+		return "ok"
+	}
+	var commandErr *CommandError
+	if err, ok := resp.(error); ok && errors.As(err, &commandErr) {
+		return string(commandErr.ErrorCode)
+	}
+	return "error"
+}
+
+// resolvedDeviceCommand contains everything needed to send a device command or to read its last event value
+type resolvedDeviceCommand struct {
+	ids              AffectedIds
+	characteristicId string
+	aspect           model.AspectNode
+	useEventValue    bool                 //the value is read from the last event instead of sending message
+	message          messages.ProtocolMsg //without task id; metadata is also set if useEventValue is true
+}
+
+// resolveDeviceCommand loads the metadata of the command and marshals the input
+func (this *Command) resolveDeviceCommand(ctx context.Context, token auth.Token, deviceId string, serviceId string, functionId string, aspectId string, input interface{}, preferEventValue bool, characteristicId string) (result resolvedDeviceCommand, code int, commandErr *CommandError) {
+	ids := AffectedIds{DeviceId: deviceId, ServiceId: serviceId, FunctionId: functionId, AspectId: aspectId}
+	result.ids = ids
+
+	start := time.Now()
+	device, err := this.iot.GetDevice(ctx, token.Jwt(), deviceId)
+	if err != nil {
+		code, commandErr = loadError(ctx, ErrCodeDeviceLoad, "unable to load device: "+err.Error(), ids)
+		return
+	}
+	service, err := this.iot.GetService(ctx, token.Jwt(), device, serviceId)
 	if err != nil {
-		return http.StatusInternalServerError, "unable to load service: " + err.Error()
+		code, commandErr = loadError(ctx, ErrCodeServiceLoad, "unable to load service: "+err.Error(), ids)
+		return
 	}
 
-	function, err := this.iot.GetFunction(functionId)
+	function, err := this.iot.GetFunction(ctx, functionId)
 	if err != nil {
-		return http.StatusInternalServerError, "unable to load function: " + err.Error()
+		code, commandErr = loadError(ctx, ErrCodeFunctionLoad, "unable to load function: "+err.Error(), ids)
+		return
 	}
 
 	if characteristicId == "" && function.ConceptId != "" {
-		concept, err := this.iot.GetConcept(function.ConceptId)
+		concept, err := this.iot.GetConcept(ctx, function.ConceptId)
 		if err != nil {
-			return http.StatusInternalServerError, "unable to load concept: " + err.Error()
+			ids.ConceptId = function.ConceptId
+			code, commandErr = loadError(ctx, ErrCodeConceptLoad, "unable to load concept: "+err.Error(), ids)
+			return
 		}
 		characteristicId = concept.BaseCharacteristicId
 	}
+	result.characteristicId = characteristicId
 
-	protocol, err := this.iot.GetProtocol(token.Jwt(), service.ProtocolId)
+	protocol, err := this.iot.GetProtocol(ctx, token.Jwt(), service.ProtocolId)
 	if err != nil {
-		return http.StatusInternalServerError, "unable to load protocol: " + err.Error()
+		ids.ProtocolId = service.ProtocolId
+		code, commandErr = loadError(ctx, ErrCodeProtocolLoad, "unable to load protocol: "+err.Error(), ids)
+		return
 	}
 
 	var aspectNode *model.AspectNode
 	if aspectId != "" {
-		temp, err := this.iot.GetAspectNode(aspectId)
+		temp, err := this.iot.GetAspectNode(ctx, aspectId)
 		if err != nil {
-			return http.StatusInternalServerError, "unable to load aspect node: " + err.Error()
+			code, commandErr = loadError(ctx, ErrCodeAspectLoad, "unable to load aspect node: "+err.Error(), ids)
+			return
 		}
 		aspectNode = &temp
+		result.aspect = temp
 	}
+	recordStage(ctx, StageLoadMetadata, start)
 
-	if isMeasuringFunctionId(functionId) && (service.Interaction == model.EVENT || (preferEventValue && service.Interaction == model.EVENT_AND_REQUEST)) {
-		aspect := model.AspectNode{}
-		if aspectNode != nil {
-			aspect = *aspectNode
-		}
-
-		this.metrics.LogGetLastEventValue(token.GetUserId(), device.Id, service.Id, functionId)
+	result.message = messages.ProtocolMsg{
+		TaskInfo: messages.TaskInfo{
+			Time:     strconv.FormatInt(util.TimeNow().Unix(), 10),
+			TenantId: token.GetUserId(),
+		},
+		Metadata: messages.Metadata{
+			Version:    3,
+			Device:     device,
+			Service:    service,
+			Protocol:   protocol,
+			ResponseTo: this.config.MetadataResponseTo,
+			ErrorTo:    this.config.MetadataErrorTo,
+		},
+		Trace: []messages.Trace{},
+	}
 
-		return this.GetLastEventValue(token, device, service, protocol, characteristicId, functionId, aspect, timeoutDuration)
+	if isMeasuringFunctionId(functionId) && (service.Interaction == model.EVENT || (preferEventValue && service.Interaction == model.EVENT_AND_REQUEST)) {
+		result.useEventValue = true
+		return result, http.StatusOK, nil
 	}
 
 	var inputCharacteristicId string
@@ -127,46 +283,90 @@ func (this *Command) deviceCommand(token auth.Token, deviceId string, serviceId
 		outputAspectNode = aspectNode
 	}
 
-	marshalledInput, err := this.marshaller.MarshalV2(service, protocol, data)
+	start = time.Now()
+	marshalCtx, marshalSpan := tracing.Start(ctx, "marshal")
+	marshalledInput, err := this.marshaller.MarshalV2(marshalCtx, service, protocol, data)
+	tracing.End(marshalSpan, err)
 	if err != nil {
-		return http.StatusInternalServerError, "unable to marshal input: " + err.Error()
+		code, commandErr = loadError(ctx, ErrCodeMarshal, "unable to marshal input: "+err.Error(), ids)
+		return
 	}
+	recordStage(ctx, StageMarshal, start)
 
-	taskId := uuid.New().String()
-	this.register.Register(taskId)
+	result.message.Request = messages.ProtocolRequest{
+		Input: marshalledInput,
+	}
+	result.message.Metadata.OutputFunctionId = outputFunctionId
+	result.message.Metadata.OutputAspectNode = outputAspectNode
+	result.message.Metadata.InputCharacteristic = inputCharacteristicId
+	result.message.Metadata.OutputCharacteristic = outputCharacteristicId
+	return result, http.StatusOK, nil
+}
 
-	protocolMessage := messages.ProtocolMsg{
-		TaskInfo: messages.TaskInfo{
-			TaskId:   taskId,
-			Time:     strconv.FormatInt(util.TimeNow().Unix(), 10),
-			TenantId: token.GetUserId(),
-		},
-		Request: messages.ProtocolRequest{
-			Input: marshalledInput,
-		},
-		Metadata: messages.Metadata{
-			Version:              3,
-			Device:               device,
-			Service:              service,
-			Protocol:             protocol,
-			OutputFunctionId:     outputFunctionId,
-			OutputAspectNode:     outputAspectNode,
-			InputCharacteristic:  inputCharacteristicId,
-			OutputCharacteristic: outputCharacteristicId,
-			ResponseTo:           this.config.MetadataResponseTo,
-			ErrorTo:              this.config.MetadataErrorTo,
-		},
-		Trace: []messages.Trace{},
+func (this *Command) getTimeoutDuration(timeout string) time.Duration {
+	if timeout != "" {
+		timeoutDuration, err := time.ParseDuration(timeout)
+		if err == nil {
+			return timeoutDuration
+		}
 	}
+	return this.config.DefaultTimeoutDuration
+}
 
-	this.metrics.LogCommandSend(token.GetUserId(), device.Id, service.Id, functionId)
+type timeoutCtxKey struct{}
 
-	err = this.producer.SendCommand(protocolMessage)
-	if err != nil {
-		log.Println("ERROR:", err)
-		this.register.Complete(taskId, http.StatusInternalServerError, "unable to produce message")
+// withTimeout applies timeout as deadline of ctx if no outer call did already.
+// inner stages (conditions, read backs, verify attempts, group and batch sub commands) share the deadline of the request.
+func (this *Command) withTimeout(ctx context.Context, timeout string) (context.Context, context.CancelFunc) {
+	if ctx.Value(timeoutCtxKey{}) != nil {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, this.getTimeoutDuration(timeout))
+	return context.WithValue(ctx, timeoutCtxKey{}, true), cancel
+}
+
+// remainingTime returns the time until the deadline of ctx
+func remainingTime(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	return max(time.Until(deadline), 0)
+}
+
+// loadError returns a timeout or cancellation error, naming the stage of code, if ctx is done
+func loadError(ctx context.Context, code ErrorCode, detail string, ids AffectedIds) (int, *CommandError) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		commandErr := NewCommandError(status.ClientClosedRequest, ErrCodeCanceled, detail, ids)
+		commandErr.Stage = errorCodeInfos[code].stage
+		return status.ClientClosedRequest, commandErr
+	}
+	if ctx.Err() != nil {
+		commandErr := NewCommandError(http.StatusRequestTimeout, ErrCodeTimeout, detail, ids)
+		commandErr.Stage = errorCodeInfos[code].stage
+		return http.StatusRequestTimeout, commandErr
+	}
+	return http.StatusInternalServerError, NewCommandError(http.StatusInternalServerError, code, detail, ids)
+}
+
+// completeResponseError converts errors received by the register into CommandError and adds the affected ids
+func completeResponseError(code int, resp interface{}, ids AffectedIds) interface{} {
+	if code == http.StatusOK {
+		return resp
+	}
+	if commandErr, ok := resp.(*CommandError); ok {
+		if commandErr.AffectedIds == (AffectedIds{}) {
+			commandErr.AffectedIds = ids
+		}
+		return commandErr
+	}
+	if code == http.StatusRequestTimeout {
+		return NewCommandError(code, ErrCodeTimeout, errorDetail(resp), ids)
+	}
+	if code == status.ClientClosedRequest {
+		return NewCommandError(code, ErrCodeCanceled, errorDetail(resp), ids)
 	}
-	return this.register.WaitWithTimeout(taskId, timeoutDuration)
+	return NewCommandError(code, ErrCodeDeviceError, errorDetail(resp), ids)
 }
 
 func isControllingFunction(function model.Function) bool {
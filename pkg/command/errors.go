@@ -0,0 +1,143 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"errors"
+	"fmt"
+)
+
+type ErrorCode string
+
+const (
+	ErrCodeDeviceLoad          ErrorCode = "device_load_failed"
+	ErrCodeDeviceTypeLoad      ErrorCode = "device_type_load_failed"
+	ErrCodeGroupLoad           ErrorCode = "group_load_failed"
+	ErrCodeServiceLoad         ErrorCode = "service_load_failed"
+	ErrCodeFunctionLoad        ErrorCode = "function_load_failed"
+	ErrCodeConceptLoad         ErrorCode = "concept_load_failed"
+	ErrCodeProtocolLoad        ErrorCode = "protocol_load_failed"
+	ErrCodeAspectLoad          ErrorCode = "aspect_load_failed"
+	ErrCodeMarshal             ErrorCode = "marshal_failed"
+	ErrCodeUnmarshal           ErrorCode = "unmarshal_failed"
+	ErrCodeProduce             ErrorCode = "produce_failed"
+	ErrCodeTimeout             ErrorCode = "timeout"
+	ErrCodeCanceled            ErrorCode = "canceled"
+	ErrCodeDeviceError         ErrorCode = "device_error"
+	ErrCodeMissingLastValue    ErrorCode = "missing_last_value"
+	ErrCodeLastValueLoad       ErrorCode = "last_value_load_failed"
+	ErrCodeInvalidRequest      ErrorCode = "invalid_request"
+	ErrCodeConditionEvaluation ErrorCode = "condition_evaluation_failed"
+	ErrCodeRolloutAborted      ErrorCode = "rollout_aborted"
+	ErrCodeRateLimited         ErrorCode = "rate_limited"
+	ErrCodeOutcomeUnknown      ErrorCode = "outcome_unknown"
+)
+
+const errorTypePrefix = "urn:infai:ses:device-command:error:"
+
+type errorCodeInfo struct {
+	title     string
+	stage     string
+	retryable bool
+}
+
+var errorCodeInfos = map[ErrorCode]errorCodeInfo{
+	ErrCodeDeviceLoad:          {title: "unable to load device", stage: "load_device", retryable: true},
+	ErrCodeDeviceTypeLoad:      {title: "unable to load device type", stage: "load_device_type", retryable: true},
+	ErrCodeGroupLoad:           {title: "unable to load device group", stage: "load_group", retryable: true},
+	ErrCodeServiceLoad:         {title: "unable to load service", stage: "load_service", retryable: true},
+	ErrCodeFunctionLoad:        {title: "unable to load function", stage: "load_function", retryable: true},
+	ErrCodeConceptLoad:         {title: "unable to load concept", stage: "load_concept", retryable: true},
+	ErrCodeProtocolLoad:        {title: "unable to load protocol", stage: "load_protocol", retryable: true},
+	ErrCodeAspectLoad:          {title: "unable to load aspect node", stage: "load_aspect", retryable: true},
+	ErrCodeMarshal:             {title: "unable to marshal input", stage: "marshal", retryable: false},
+	ErrCodeUnmarshal:           {title: "unable to unmarshal output", stage: "unmarshal", retryable: false},
+	ErrCodeProduce:             {title: "unable to produce message", stage: "produce", retryable: true},
+	ErrCodeTimeout:             {title: "timeout", stage: "wait_for_response", retryable: true},
+	ErrCodeCanceled:            {title: "request canceled", stage: "wait_for_response", retryable: true},
+	ErrCodeDeviceError:         {title: "device error", stage: "device", retryable: false},
+	ErrCodeMissingLastValue:    {title: "missing last value", stage: "last_event_value", retryable: false},
+	ErrCodeLastValueLoad:       {title: "unable to get event value", stage: "last_event_value", retryable: true},
+	ErrCodeInvalidRequest:      {title: "invalid request", stage: "validate", retryable: false},
+	ErrCodeConditionEvaluation: {title: "unable to evaluate condition", stage: "condition", retryable: false},
+	ErrCodeRolloutAborted:      {title: "command not sent", stage: "rollout", retryable: true},
+	ErrCodeRateLimited:         {title: "too many commands", stage: "rate_limit", retryable: true},
+	ErrCodeOutcomeUnknown:      {title: "outcome unknown", stage: "idempotency", retryable: false},
+}
+
+// AffectedIds references the entities involved in a failed command
+type AffectedIds struct {
+	DeviceId   string `json:"device_id,omitempty"`
+	ServiceId  string `json:"service_id,omitempty"`
+	GroupId    string `json:"group_id,omitempty"`
+	FunctionId string `json:"function_id,omitempty"`
+	AspectId   string `json:"aspect_id,omitempty"`
+	ConceptId  string `json:"concept_id,omitempty"`
+	ProtocolId string `json:"protocol_id,omitempty"`
+}
+
+// CommandError is a RFC 7807 problem with a stable error_code
+type CommandError struct {
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Status    int       `json:"status"`
+	Detail    string    `json:"detail"`
+	ErrorCode ErrorCode `json:"error_code"`
+	Stage     string    `json:"stage"`
+	Retryable bool      `json:"retryable"`
+	AffectedIds
+	DeviceOutput interface{} `json:"device_output,omitempty"` //error output of the device, if error_code is device_error
+	RetryAfter   int64       `json:"retry_after,omitempty"`   //seconds until the command may be retried, if error_code is rate_limited
+}
+
+func NewCommandError(status int, code ErrorCode, detail string, ids AffectedIds) *CommandError {
+	info := errorCodeInfos[code]
+	return &CommandError{
+		Type:        errorTypePrefix + string(code),
+		Title:       info.title,
+		Status:      status,
+		Detail:      detail,
+		ErrorCode:   code,
+		Stage:       info.stage,
+		Retryable:   info.retryable,
+		AffectedIds: ids,
+	}
+}
+
+func (this *CommandError) Error() string {
+	return this.Detail
+}
+
+// LegacyMessage returns the value used as message before structured errors were introduced
+func (this *CommandError) LegacyMessage() interface{} {
+	if this.DeviceOutput != nil {
+		return this.DeviceOutput
+	}
+	return this.Detail
+}
+
+// errorDetail returns the detail of CommandError responses and formats all other responses
+func errorDetail(resp interface{}) string {
+	var commandErr *CommandError
+	if err, ok := resp.(error); ok && errors.As(err, &commandErr) {
+		return commandErr.Detail
+	}
+	if str, ok := resp.(string); ok {
+		return str
+	}
+	return fmt.Sprint(resp)
+}
@@ -17,6 +17,7 @@
 package command
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/SENERGY-Platform/device-command/pkg/auth"
@@ -31,12 +32,18 @@ import (
 	"time"
 )
 
-func (this *Command) GetLastEventValue(token auth.Token, device model.Device, service model.Service, protocol model.Protocol, characteristicId string, functionId string, aspect model.AspectNode, timeout time.Duration) (code int, result interface{}) {
-	output, err, code := this.getLastEventMessage(token, device, service, protocol, timeout)
+func (this *Command) GetLastEventValue(ctx context.Context, token auth.Token, device model.Device, service model.Service, protocol model.Protocol, characteristicId string, functionId string, aspect model.AspectNode) (code int, result interface{}) {
+	ids := AffectedIds{DeviceId: device.Id, ServiceId: service.Id, FunctionId: functionId, AspectId: aspect.Id}
+	start := time.Now()
+	defer recordStage(ctx, StageLastEventValue, start)
+	output, err, code := this.getLastEventMessage(ctx, token, device, service, protocol)
+	if errors.Is(err, interfaces.ErrMissingLastValue) {
+		return code, NewCommandError(code, ErrCodeMissingLastValue, "unable to get event value: "+err.Error(), ids)
+	}
 	if err != nil {
-		return code, "unable to get event value: " + err.Error()
+		return loadError(ctx, ErrCodeLastValueLoad, "unable to get event value: "+err.Error(), ids)
 	}
-	temp, err := this.marshaller.UnmarshalV2(marshaller.UnmarshallingV2Request{
+	temp, err := this.marshaller.UnmarshalV2(ctx, marshaller.UnmarshallingV2Request{
 		Service:          service,
 		Protocol:         protocol,
 		CharacteristicId: characteristicId,
@@ -59,15 +66,15 @@ func (this *Command) GetLastEventValue(token auth.Token, device model.Device, se
 			})
 			log.Println("ERROR: unmarshal request", string(marshalRequestStr))
 		}
-		return http.StatusInternalServerError, "unable to unmarshal event value: " + err.Error()
+		return http.StatusInternalServerError, NewCommandError(http.StatusInternalServerError, ErrCodeUnmarshal, "unable to unmarshal event value: "+err.Error(), ids)
 	}
 	return 200, temp
 }
 
-func (this *Command) getLastEventMessage(token auth.Token, device model.Device, service model.Service, protocol model.Protocol, timeout time.Duration) (result map[string]string, err error, code int) {
-	response, err := this.timescale.GetLastMessage(token, device, service, protocol, timeout)
+func (this *Command) getLastEventMessage(ctx context.Context, token auth.Token, device model.Device, service model.Service, protocol model.Protocol) (result map[string]string, err error, code int) {
+	response, err := this.timescale.GetLastMessage(ctx, token, device, service, protocol)
 	if errors.Is(err, interfaces.ErrMissingLastValue) {
-		return result, err, interfaces.ErrMissingLastValueCode
+		return result, err, this.missingLastValueCode()
 	}
 	if err != nil {
 		return result, err, http.StatusInternalServerError
@@ -76,6 +83,14 @@ func (this *Command) getLastEventMessage(token auth.Token, device model.Device,
 	return result, err, code
 }
 
+// missingLastValueCode is the legacy interfaces.ErrMissingLastValueCode unless missing_last_value_not_found is set
+func (this *Command) missingLastValueCode() int {
+	if this.config.MissingLastValueNotFound {
+		return http.StatusNotFound
+	}
+	return interfaces.ErrMissingLastValueCode
+}
+
 func (this *Command) useProtocolSerialization(service model.Service, protocol model.Protocol, lastMsg map[string]interface{}) (result map[string]string, err error, code int) {
 	result = map[string]string{}
 	for _, content := range service.Outputs {
@@ -0,0 +1,47 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/interfaces"
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+)
+
+func TestMissingLastValueStatusCode(t *testing.T) {
+	for _, test := range []struct {
+		notFound bool
+		expected int
+	}{
+		{notFound: false, expected: interfaces.ErrMissingLastValueCode},
+		{notFound: true, expected: http.StatusNotFound},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		cmd := newTestCommand(t, ctx, newDeviceMock(), nil, func(config *configuration.Config) {
+			config.MissingLastValueNotFound = test.notFound
+		})
+		code, resp := cmd.Command(ctx, testToken(t), CommandMessage{FunctionId: testGetFunctionId, DeviceId: "unknown", ServiceId: "service"}, "5s", true)
+		commandErr, ok := resp.(*CommandError)
+		if code != test.expected || !ok || commandErr.Status != test.expected || commandErr.ErrorCode != ErrCodeMissingLastValue {
+			t.Errorf("%v: %v %#v", test.notFound, code, resp)
+		}
+	}
+}
@@ -17,45 +17,118 @@
 package command
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"sort"
-	"sync"
 
 	"github.com/SENERGY-Platform/device-command/pkg/auth"
 	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
 )
 
-func (this *Command) GroupCommand(token auth.Token, groupId string, functionId string, aspectId string, deviceClassId string, input interface{}, timeout string, preferEventValue bool, characteristicId string) (code int, resp interface{}) {
-	subTasks, err := this.GetSubTasks(token.Jwt(), groupId, functionId, aspectId, deviceClassId, input)
+// GroupSubResult is the result of one sub command of a group command
+type GroupSubResult struct {
+	DeviceId   string        `json:"device_id"`
+	ServiceId  string        `json:"service_id"`
+	StatusCode int           `json:"status_code"`
+	Value      interface{}   `json:"value,omitempty"`
+	Error      *CommandError `json:"error,omitempty"`
+}
+
+// GroupResult is the response of a group command with group_result_details
+type GroupResult struct {
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []GroupSubResult `json:"results"` //in the order of the sub commands
+}
+
+func (this *Command) GroupCommand(ctx context.Context, token auth.Token, groupId string, functionId string, aspectId string, deviceClassId string, input interface{}, timeout string, preferEventValue bool, characteristicId string) (code int, resp interface{}) {
+	return this.groupCommand(ctx, token, CommandMessage{
+		FunctionId:       functionId,
+		AspectId:         aspectId,
+		Input:            input,
+		GroupId:          groupId,
+		DeviceClassId:    deviceClassId,
+		CharacteristicId: characteristicId,
+	}, timeout, preferEventValue)
+}
+
+// groupCommand responds with the list of successful sub results, with a GroupResult if cmd.GroupResultDetails is set
+// or with an AggregationResult if cmd.Aggregation is set.
+// the status code is 200 if at least one sub command succeeded or the group has no matching services
+func (this *Command) groupCommand(ctx context.Context, token auth.Token, cmd CommandMessage, timeout string, preferEventValue bool) (code int, resp interface{}) {
+	subTasks, err := this.GetSubTasks(ctx, token.Jwt(), cmd.GroupId, cmd.FunctionId, cmd.AspectId, cmd.DeviceClassId, cmd.Input)
 	if err != nil {
-		return http.StatusInternalServerError, err.Error()
+		return http.StatusInternalServerError, NewCommandError(http.StatusInternalServerError, ErrCodeGroupLoad, err.Error(), AffectedIds{GroupId: cmd.GroupId, FunctionId: cmd.FunctionId, AspectId: cmd.AspectId})
 	}
-	wg := sync.WaitGroup{}
-	results := []interface{}{}
+	subResults := this.runSubTasks(ctx, token, subTasks, cmd.Input, timeout, preferEventValue, cmd.CharacteristicId, this.getRollout(cmd.Rollout))
+
+	code = http.StatusOK
+	result := GroupResult{Results: subResults}
+	values := []interface{}{}
 	var lastErr interface{}
-	var lastErrCode int
-	for _, sub := range subTasks {
-		wg.Add(1)
-		go func(sub SubCommand) {
-			defer wg.Done()
-			tempCode, temp := this.deviceCommand(token, sub.DeviceId, sub.ServiceId, sub.FunctionId, sub.AspectId, input, timeout, preferEventValue, characteristicId)
-			if this.config.Debug {
-				log.Println("DEBUG: group sub result:", tempCode, temp)
-			}
-			if tempCode == http.StatusOK {
-				results = append(results, temp)
-			} else {
-				lastErr = temp
-				lastErrCode = tempCode
-			}
-		}(sub)
+	for _, sub := range subResults {
+		if sub.StatusCode == http.StatusOK {
+			result.Succeeded++
+			values = append(values, sub.Value)
+		} else {
+			result.Failed++
+			lastErr = sub.Error
+			code = sub.StatusCode
+		}
 	}
-	wg.Wait()
-	if len(results) == 0 && len(subTasks) > 0 {
-		return lastErrCode, lastErr
+	if result.Succeeded > 0 || len(subResults) == 0 {
+		code = http.StatusOK
+	}
+	if code != http.StatusOK && !cmd.GroupResultDetails {
+		return code, lastErr
+	}
+	if cmd.Aggregation != "" {
+		aggregated := aggregate(cmd.Aggregation, subResults)
+		if cmd.GroupResultDetails {
+			aggregated.Details = &result
+		}
+		return code, aggregated
 	}
-	return http.StatusOK, results
+	if cmd.GroupResultDetails {
+		return code, result
+	}
+	return code, values
+}
+
+func (this *Command) runSubTasks(ctx context.Context, token auth.Token, subTasks []SubCommand, input interface{}, timeout string, preferEventValue bool, characteristicId string, rollout Rollout) []GroupSubResult {
+	results := make([]GroupSubResult, len(subTasks))
+	skipped := runRollout(ctx, rollout, len(subTasks), func(i int) bool {
+		sub := subTasks[i]
+		tempCode, temp := this.deviceCommand(ctx, token, sub.DeviceId, sub.ServiceId, sub.FunctionId, sub.AspectId, input, timeout, preferEventValue, characteristicId, "")
+		if this.config.Debug {
+			log.Println("DEBUG: group sub result:", tempCode, temp)
+		}
+		//each call writes only its own index
+		results[i] = newGroupSubResult(sub, tempCode, temp)
+		return tempCode == http.StatusOK
+	})
+	for i, sub := range subTasks {
+		if skipped[i] {
+			code, err := skippedError(ctx, AffectedIds{DeviceId: sub.DeviceId, ServiceId: sub.ServiceId, FunctionId: sub.FunctionId, AspectId: sub.AspectId})
+			results[i] = newGroupSubResult(sub, code, err)
+		}
+	}
+	return results
+}
+
+func newGroupSubResult(sub SubCommand, code int, resp interface{}) GroupSubResult {
+	result := GroupSubResult{DeviceId: sub.DeviceId, ServiceId: sub.ServiceId, StatusCode: code}
+	if code == http.StatusOK {
+		result.Value = resp
+		return result
+	}
+	commandErr, ok := resp.(*CommandError)
+	if !ok {
+		commandErr = completeResponseError(code, resp, AffectedIds{DeviceId: sub.DeviceId, ServiceId: sub.ServiceId, FunctionId: sub.FunctionId, AspectId: sub.AspectId}).(*CommandError)
+	}
+	result.Error = commandErr
+	return result
 }
 
 type SubCommand struct {
@@ -66,25 +139,25 @@ type SubCommand struct {
 	ServiceId  string      `json:"service_id,omitempty"`
 }
 
-func (this *Command) GetSubTasks(token string, deviceGroupId string, functionId string, aspectId string, deviceClassId string, input interface{}) (result []SubCommand, err error) {
-	group, err := this.iot.GetDeviceGroup(token, deviceGroupId)
+func (this *Command) GetSubTasks(ctx context.Context, token string, deviceGroupId string, functionId string, aspectId string, deviceClassId string, input interface{}) (result []SubCommand, err error) {
+	group, err := this.iot.GetDeviceGroup(ctx, token, deviceGroupId)
 	if err != nil {
 		return nil, err
 	}
 	for _, deviceId := range group.DeviceIds {
-		device, err := this.iot.GetDevice(token, deviceId)
+		device, err := this.iot.GetDevice(ctx, token, deviceId)
 		if err != nil {
 			return nil, err
 		}
 
-		deviceType, err := this.iot.GetDeviceType(token, device.DeviceTypeId)
+		deviceType, err := this.iot.GetDeviceType(ctx, token, device.DeviceTypeId)
 		if err != nil {
 			return nil, err
 		}
 
 		aspect := model.AspectNode{}
 		if aspectId != "" {
-			aspect, err = this.iot.GetAspectNode(aspectId)
+			aspect, err = this.iot.GetAspectNode(ctx, aspectId)
 			if err != nil {
 				log.Println("WARNING: unable to find aspect node, use aspect node without descendants", err)
 				aspect.Id = aspectId
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGroupCommandResultDetails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := newDeviceMock()
+	devices.failing["broken"] = true
+	cmd := newTestCommand(t, ctx, devices, map[string][]string{
+		"group":  {"lamp1", "broken", "lamp2"},
+		"failed": {"broken"},
+	}, nil)
+	token := testToken(t)
+
+	t.Run("partial failure", func(t *testing.T) {
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, GroupId: "group", Input: 7, GroupResultDetails: true}, "5s", false)
+		result, ok := resp.(GroupResult)
+		if code != http.StatusOK || !ok {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if result.Succeeded != 2 || result.Failed != 1 || len(result.Results) != 3 {
+			t.Errorf("%#v", result)
+			return
+		}
+		for i, deviceId := range []string{"lamp1", "broken", "lamp2"} {
+			sub := result.Results[i]
+			if sub.DeviceId != deviceId || sub.ServiceId != "service" {
+				t.Errorf("%v %#v", i, sub)
+			}
+			if deviceId == "broken" {
+				if sub.StatusCode == http.StatusOK || sub.Error == nil || sub.Error.ErrorCode != ErrCodeDeviceError || sub.Error.AffectedIds.DeviceId != "broken" {
+					t.Errorf("%v %#v", i, sub)
+				}
+			} else if sub.StatusCode != http.StatusOK || sub.Error != nil {
+				t.Errorf("%v %#v", i, sub)
+			}
+		}
+		if devices.get("lamp1") != 7.0 || devices.get("lamp2") != 7.0 {
+			t.Error(devices.get("lamp1"), devices.get("lamp2"))
+		}
+	})
+
+	t.Run("all failed", func(t *testing.T) {
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, GroupId: "failed", Input: 7, GroupResultDetails: true}, "5s", false)
+		result, ok := resp.(GroupResult)
+		if code == http.StatusOK || !ok {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if result.Succeeded != 0 || result.Failed != 1 || len(result.Results) != 1 || result.Results[0].StatusCode != code {
+			t.Errorf("%#v", result)
+		}
+	})
+
+	t.Run("without details", func(t *testing.T) {
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, GroupId: "group", Input: 8}, "5s", false)
+		values, ok := resp.([]interface{})
+		if code != http.StatusOK || !ok || len(values) != 2 {
+			t.Errorf("%v %#v", code, resp)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/interfaces"
+)
+
+const (
+	HealthStatusOk          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// ComponentHealth is reported without error details, which may contain internal addresses; failed checks are logged
+type ComponentHealth struct {
+	Status string `json:"status"`
+}
+
+// addHealthCheck registers dependency for readiness checks, if it implements interfaces.HealthChecker
+func (this *Command) addHealthCheck(name string, dependency interface{}) {
+	checker, ok := dependency.(interfaces.HealthChecker)
+	if !ok {
+		return
+	}
+	if this.healthChecks == nil {
+		this.healthChecks = map[string]interfaces.HealthChecker{}
+	}
+	this.healthChecks[name] = checker
+}
+
+// Health checks all dependencies concurrently; ready is false if any dependency is unavailable
+func (this *Command) Health(ctx context.Context) (ready bool, report HealthReport) {
+	ctx, cancel := context.WithTimeout(ctx, this.healthCheckTimeout)
+	defer cancel()
+	report = HealthReport{Status: HealthStatusOk, Components: map[string]ComponentHealth{}}
+	mux := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, checker := range this.healthChecks {
+		wg.Add(1)
+		go func(name string, checker interfaces.HealthChecker) {
+			defer wg.Done()
+			start := time.Now()
+			err := checker.HealthCheck(ctx)
+			component := ComponentHealth{Status: HealthStatusOk}
+			if err != nil {
+				component.Status = HealthStatusUnavailable
+				log.Println("ERROR: health check failed", name, time.Since(start), err)
+			} else if this.config.Debug {
+				log.Println("DEBUG: health check", name, time.Since(start))
+			}
+			mux.Lock()
+			defer mux.Unlock()
+			report.Components[name] = component
+			if err != nil {
+				report.Status = HealthStatusUnavailable
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+	return report.Status == HealthStatusOk, report
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type healthCheckerMock struct {
+	err error
+}
+
+func (this healthCheckerMock) HealthCheck(ctx context.Context) error {
+	return this.err
+}
+
+func TestHealthReportHidesErrorDetails(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := newTestCommand(t, ctx, newDeviceMock(), nil, nil)
+	cmd.addHealthCheck("kafka", healthCheckerMock{})
+	cmd.addHealthCheck("iot", healthCheckerMock{err: errors.New("dial tcp 10.0.0.1:8080: connection refused")})
+
+	ready, report := cmd.Health(ctx)
+	if ready || report.Status != HealthStatusUnavailable {
+		t.Error(ready, report)
+	}
+	if report.Components["kafka"].Status != HealthStatusOk || report.Components["iot"].Status != HealthStatusUnavailable {
+		t.Error(report)
+	}
+	temp, err := json.Marshal(report)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if strings.Contains(string(temp), "10.0.0.1") {
+		t.Error(string(temp))
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/device-command/pkg/history"
+	"github.com/SENERGY-Platform/device-command/pkg/storage"
+)
+
+// DeviceHistoryEntry is a command sent to a device and its result
+type DeviceHistoryEntry struct {
+	Time    time.Time          `json:"time"`
+	UserId  string             `json:"user_id"`
+	Command CommandMessage     `json:"command"`
+	Result  BatchResultElement `json:"result"`
+}
+
+// newDeviceHistory returns nil if device_history_size is 0; histories are keyed by device id
+func newDeviceHistory(ctx context.Context, config configuration.Config) (*history.Store[DeviceHistoryEntry], error) {
+	if config.DeviceHistorySize <= 0 {
+		return nil, nil
+	}
+	backend, err := storage.New[history.Stored[DeviceHistoryEntry]](config.DeviceHistoryDir)
+	if err != nil {
+		return nil, err
+	}
+	return history.New(ctx, int(config.DeviceHistorySize), backend)
+}
+
+func (this *Command) addDeviceHistory(deviceId string, entry DeviceHistoryEntry) {
+	if this.history != nil {
+		this.history.Add(deviceId, entry)
+	}
+}
+
+// GetDeviceHistory returns the last commands sent to the device, most recent last.
+// the device is loaded with token to ensure the user has access to it.
+func (this *Command) GetDeviceHistory(ctx context.Context, token auth.Token, deviceId string) (code int, resp interface{}) {
+	if this.history == nil {
+		return http.StatusNotImplemented, NewCommandError(http.StatusNotImplemented, ErrCodeInvalidRequest, "device history is disabled", AffectedIds{DeviceId: deviceId})
+	}
+	_, err := this.iot.GetDevice(ctx, token.Jwt(), deviceId)
+	if err != nil {
+		return loadError(ctx, ErrCodeDeviceLoad, "unable to load device: "+err.Error(), AffectedIds{DeviceId: deviceId})
+	}
+	return http.StatusOK, this.history.List(deviceId)
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/idempotency"
+	"github.com/SENERGY-Platform/device-command/pkg/status"
+)
+
+// Idempotent calls f at most once per user, endpoint and key within the configured idempotency_window.
+// repeated calls return the stored result (replayed = true) or join the still running call.
+// f is called directly if key is empty or idempotency_window is disabled.
+// returns idempotency.ErrKeyReused if the key was used for a different request.
+// returns idempotency.ErrOutcomeUnknown if the first call was canceled or timed out, because its commands may have been sent.
+func (this *Command) Idempotent(ctx context.Context, token auth.Token, endpoint string, key string, request interface{}, f func() (code int, resp interface{})) (code int, resp interface{}, replayed bool, err error) {
+	if key == "" || this.idempotency == nil {
+		code, resp = f()
+		return code, resp, false, nil
+	}
+	return this.idempotency.Do(ctx, token.GetUserId(), endpoint+" "+key, idempotency.Fingerprint(request), f, knownIdempotentResult)
+}
+
+// knownIdempotentResult rejects batch results with canceled or timed out elements, whose commands may have been sent
+func knownIdempotentResult(code int, resp interface{}) bool {
+	elements, ok := resp.([]BatchResultElement)
+	if !ok {
+		return true
+	}
+	for _, element := range elements {
+		if element.StatusCode == status.ClientClosedRequest || element.StatusCode == http.StatusRequestTimeout {
+			return false
+		}
+	}
+	return true
+}
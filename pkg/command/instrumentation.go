@@ -0,0 +1,162 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/interfaces"
+	"github.com/SENERGY-Platform/device-command/pkg/command/metrics"
+	"github.com/SENERGY-Platform/device-command/pkg/tracing"
+	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
+	"github.com/SENERGY-Platform/models/go/models"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// instrumentedIot records a span and the latency for each metadata lookup
+type instrumentedIot struct {
+	iot     interfaces.Iot
+	metrics *metrics.Metrics
+}
+
+func (this instrumentedIot) GetDevice(ctx context.Context, token string, id string) (result model.Device, err error) {
+	ctx, span := tracing.Start(ctx, "iot.GetDevice", attribute.String("device_id", id))
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("GetDevice", time.Since(start))
+	}()
+	return this.iot.GetDevice(ctx, token, id)
+}
+
+func (this instrumentedIot) GetDeviceGroup(ctx context.Context, token string, id string) (result model.DeviceGroup, err error) {
+	ctx, span := tracing.Start(ctx, "iot.GetDeviceGroup", attribute.String("group_id", id))
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("GetDeviceGroup", time.Since(start))
+	}()
+	return this.iot.GetDeviceGroup(ctx, token, id)
+}
+
+func (this instrumentedIot) GetService(ctx context.Context, token string, device model.Device, id string) (result model.Service, err error) {
+	ctx, span := tracing.Start(ctx, "iot.GetService", attribute.String("device_id", device.Id), attribute.String("service_id", id))
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("GetService", time.Since(start))
+	}()
+	return this.iot.GetService(ctx, token, device, id)
+}
+
+func (this instrumentedIot) GetDeviceType(ctx context.Context, token string, id string) (result model.DeviceType, err error) {
+	ctx, span := tracing.Start(ctx, "iot.GetDeviceType", attribute.String("device_type_id", id))
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("GetDeviceType", time.Since(start))
+	}()
+	return this.iot.GetDeviceType(ctx, token, id)
+}
+
+func (this instrumentedIot) GetProtocol(ctx context.Context, token string, id string) (result model.Protocol, err error) {
+	ctx, span := tracing.Start(ctx, "iot.GetProtocol", attribute.String("protocol_id", id))
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("GetProtocol", time.Since(start))
+	}()
+	return this.iot.GetProtocol(ctx, token, id)
+}
+
+func (this instrumentedIot) ListFunctions(ctx context.Context) (result []model.Function, err error) {
+	ctx, span := tracing.Start(ctx, "iot.ListFunctions")
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("ListFunctions", time.Since(start))
+	}()
+	return this.iot.ListFunctions(ctx)
+}
+
+func (this instrumentedIot) GetFunction(ctx context.Context, id string) (result model.Function, err error) {
+	ctx, span := tracing.Start(ctx, "iot.GetFunction", attribute.String("function_id", id))
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("GetFunction", time.Since(start))
+	}()
+	return this.iot.GetFunction(ctx, id)
+}
+
+func (this instrumentedIot) GetConcept(ctx context.Context, id string) (result model.Concept, err error) {
+	ctx, span := tracing.Start(ctx, "iot.GetConcept", attribute.String("concept_id", id))
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("GetConcept", time.Since(start))
+	}()
+	return this.iot.GetConcept(ctx, id)
+}
+
+func (this instrumentedIot) GetCharacteristic(ctx context.Context, id string) (result model.Characteristic, err error) {
+	ctx, span := tracing.Start(ctx, "iot.GetCharacteristic", attribute.String("characteristic_id", id))
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("GetCharacteristic", time.Since(start))
+	}()
+	return this.iot.GetCharacteristic(ctx, id)
+}
+
+func (this instrumentedIot) GetAspectNode(ctx context.Context, id string) (result model.AspectNode, err error) {
+	ctx, span := tracing.Start(ctx, "iot.GetAspectNode", attribute.String("aspect_id", id))
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("GetAspectNode", time.Since(start))
+	}()
+	return this.iot.GetAspectNode(ctx, id)
+}
+
+func (this instrumentedIot) GetConceptIds(ctx context.Context) (result []string, err error) {
+	ctx, span := tracing.Start(ctx, "iot.GetConceptIds")
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveIotRequest("GetConceptIds", time.Since(start))
+	}()
+	return this.iot.GetConceptIds(ctx)
+}
+
+// instrumentedTimescale records a span and the latency for each last-event-value request
+type instrumentedTimescale struct {
+	timescale interfaces.Timescale
+	metrics   *metrics.Metrics
+}
+
+func (this instrumentedTimescale) GetLastMessage(ctx context.Context, token auth.Token, device models.Device, service models.Service, protocol model.Protocol) (result map[string]interface{}, err error) {
+	ctx, span := tracing.Start(ctx, "timescale.GetLastMessage", attribute.String("device_id", device.Id), attribute.String("service_id", service.Id))
+	start := time.Now()
+	defer func() {
+		tracing.End(span, err)
+		this.metrics.ObserveTimescaleRequest(time.Since(start))
+	}()
+	return this.timescale.GetLastMessage(ctx, token, device, service, protocol)
+}
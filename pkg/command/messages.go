@@ -20,8 +20,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"hash/maphash"
 	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/callback"
 )
 
 type CommandMessage struct {
@@ -38,6 +42,22 @@ type CommandMessage struct {
 
 	DeviceClassId    string `json:"device_class_id,omitempty"`
 	CharacteristicId string `json:"characteristic_id,omitempty"`
+
+	CallbackUrl string `json:"callback_url,omitempty"` //optional; the result is posted to this url
+
+	Condition *Condition `json:"condition,omitempty"` //optional; the command is only sent if the condition holds
+
+	ReturnPrevious bool `json:"return_previous,omitempty"` //optional; only for device commands with controlling functions; the current value is read before the command is sent
+
+	Verify *Verification `json:"verify,omitempty"` //optional; only for device commands with controlling functions; the resulting value is read back and compared to input
+
+	GroupResultDetails bool `json:"group_result_details,omitempty"` //optional; only for group commands; responds with a GroupResult listing each sub command
+
+	Aggregation string `json:"aggregation,omitempty"` //optional; only for group commands with measuring functions; "min" || "max" || "avg" || "sum" || "median" || "count" || "first" || "last" || "all-equal"
+
+	Rollout *Rollout `json:"rollout,omitempty"` //optional; only for group commands; defaults to the group_* config values
+
+	MaxAge string `json:"max_age,omitempty"` //optional; only for measuring functions; results of identical requests (by any user) which are not older than max_age (e.g. "10s") are reused
 }
 
 func (this CommandMessage) Validate() error {
@@ -45,6 +65,58 @@ func (this CommandMessage) Validate() error {
 		return errors.New("expect function_id in body")
 	}
 
+	if this.CallbackUrl != "" {
+		err := callback.ValidateUrl(this.CallbackUrl)
+		if err != nil {
+			return err
+		}
+	}
+
+	if this.Condition != nil {
+		err := this.Condition.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if this.ReturnPrevious && (this.DeviceId == "" || this.ServiceId == "") {
+		return errors.New("return_previous is only supported for device commands")
+	}
+
+	if this.Verify != nil {
+		if this.DeviceId == "" || this.ServiceId == "" {
+			return errors.New("verify is only supported for device commands")
+		}
+		err := this.Verify.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if this.GroupResultDetails && this.GroupId == "" {
+		return errors.New("group_result_details is only supported for group commands")
+	}
+
+	err := this.ValidateAggregation()
+	if err != nil {
+		return err
+	}
+
+	err = this.ValidateMaxAge()
+	if err != nil {
+		return err
+	}
+
+	if this.Rollout != nil {
+		if this.GroupId == "" {
+			return errors.New("rollout is only supported for group commands")
+		}
+		err = this.Rollout.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
 	if this.DeviceId != "" && this.ServiceId != "" {
 		return nil
 	}
@@ -56,6 +128,23 @@ func (this CommandMessage) Validate() error {
 	return errors.New("missing device_id, service_id or group_id")
 }
 
+func (this CommandMessage) ValidateMaxAge() error {
+	if this.MaxAge == "" {
+		return nil
+	}
+	maxAge, err := time.ParseDuration(this.MaxAge)
+	if err != nil {
+		return fmt.Errorf("invalid max_age: %w", err)
+	}
+	if maxAge < 0 {
+		return errors.New("max_age may not be negative")
+	}
+	if !isMeasuringFunctionId(this.FunctionId) {
+		return errors.New("max_age is only supported for measuring functions")
+	}
+	return nil
+}
+
 func (this CommandMessage) Hash(seed maphash.Seed) uint64 {
 	var b bytes.Buffer
 	json.NewEncoder(&b).Encode(this)
@@ -75,6 +164,25 @@ func (this BatchRequest) Validate() error {
 }
 
 type BatchResultElement struct {
-	StatusCode int         `json:"status_code"`
-	Message    interface{} `json:"message"`
+	StatusCode int           `json:"status_code"`
+	Message    interface{}   `json:"message"`
+	Error      *CommandError `json:"error,omitempty"` //structured error; message keeps the plain error text
+}
+
+func NewBatchResultElement(code int, resp interface{}) BatchResultElement {
+	if commandErr, ok := resp.(*CommandError); ok {
+		return BatchResultElement{StatusCode: code, Message: commandErr.LegacyMessage(), Error: commandErr}
+	}
+	return BatchResultElement{StatusCode: code, Message: resp}
+}
+
+type BatchStreamElement struct {
+	Index int `json:"index"`
+	BatchResultElement
+}
+
+type BatchStreamSummary struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
 }
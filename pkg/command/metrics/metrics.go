@@ -17,15 +17,20 @@
 package metrics
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"net/http"
 )
 
-func New() *Metrics {
+// New creates the metrics; if highCardinalityLabels is false, the user_id and device_id labels are left empty
+func New(highCardinalityLabels bool) *Metrics {
 	reg := prometheus.NewRegistry()
 
 	result := &Metrics{
+		registry:              reg,
+		highCardinalityLabels: highCardinalityLabels,
 		httphandler: promhttp.HandlerFor(
 			reg,
 			promhttp.HandlerOpts{
@@ -40,46 +45,173 @@ func New() *Metrics {
 			Name: "device_command_last_event_value_request_count_vec",
 			Help: "counter vec for last-event-value requests",
 		}, []string{"user_id", "device_id", "service_id", "function_id"}),
+		resultCacheHitCountVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "device_command_result_cache_hit_count_vec",
+			Help: "counter vec for measuring commands answered by the result cache or by a concurrent identical request",
+		}, []string{"user_id", "device_id", "service_id", "function_id"}),
+		resultCacheMissCountVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "device_command_result_cache_miss_count_vec",
+			Help: "counter vec for measuring commands with max_age which had to be sent to the device",
+		}, []string{"user_id", "device_id", "service_id", "function_id"}),
+		throttledCountVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "device_command_throttled_count_vec",
+			Help: "counter vec for commands rejected by rate limits",
+		}, []string{"user_id", "scope"}),
 		requestsCountVec: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Name: "device_command_requests_count_vec",
 			Help: "counter vec for requests",
 		}, []string{"user_id", "endpoint"}),
+		outcomeCountVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "device_command_outcome_count_vec",
+			Help: "counter vec for device command results by outcome (ok or error code, e.g. timeout, device_error, marshal_failed, missing_last_value)",
+		}, []string{"user_id", "device_id", "outcome"}),
+		commandLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "device_command_command_latency_seconds",
+			Help:    "end-to-end latency of device commands, including metadata loading and waiting for the response",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+		}, []string{"outcome"}),
+		registerWaitLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "device_command_register_wait_seconds",
+			Help:    "time device commands wait in the register for their response",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+		}),
+		iotLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "device_command_iot_request_seconds",
+			Help:    "latency of metadata lookups by iot method",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+		}, []string{"method"}),
+		timescaleLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "device_command_timescale_request_seconds",
+			Help:    "latency of last-event-value requests to timescale",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+		}),
 	}
 
 	reg.MustRegister(
 		result.commandsSendCountVec,
 		result.lastEventValueRequestCountVec,
+		result.resultCacheHitCountVec,
+		result.resultCacheMissCountVec,
+		result.throttledCountVec,
 		result.requestsCountVec,
+		result.outcomeCountVec,
+		result.commandLatency,
+		result.registerWaitLatency,
+		result.iotLatency,
+		result.timescaleLatency,
 	)
 
 	return result
 }
 
 type Metrics struct {
-	httphandler http.Handler
+	httphandler           http.Handler
+	registry              *prometheus.Registry
+	highCardinalityLabels bool
 
 	commandsSendCountVec          *prometheus.CounterVec
 	lastEventValueRequestCountVec *prometheus.CounterVec
+	resultCacheHitCountVec        *prometheus.CounterVec
+	resultCacheMissCountVec       *prometheus.CounterVec
+	throttledCountVec             *prometheus.CounterVec
 	requestsCountVec              *prometheus.CounterVec
+	outcomeCountVec               *prometheus.CounterVec
+
+	commandLatency      *prometheus.HistogramVec
+	registerWaitLatency prometheus.Histogram
+	iotLatency          *prometheus.HistogramVec
+	timescaleLatency    prometheus.Histogram
+}
+
+// label returns value if high cardinality labels are enabled
+func (this *Metrics) label(value string) string {
+	if !this.highCardinalityLabels {
+		return ""
+	}
+	return value
+}
+
+// SetPendingRegisterEntriesSource adds a gauge of pending register entries, read from pending on each scrape
+func (this *Metrics) SetPendingRegisterEntriesSource(pending func() int) {
+	if this == nil {
+		return
+	}
+	this.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "device_command_pending_register_entries",
+		Help: "number of device commands waiting for their response",
+	}, func() float64 {
+		return float64(pending())
+	}))
 }
 
 func (this *Metrics) LogCommandSend(userId string, deviceId string, serviceId string, functionId string) {
 	if this == nil {
 		return
 	}
-	this.commandsSendCountVec.WithLabelValues(userId, deviceId, serviceId, functionId).Inc()
+	this.commandsSendCountVec.WithLabelValues(this.label(userId), this.label(deviceId), serviceId, functionId).Inc()
 }
 
 func (this *Metrics) LogGetLastEventValue(userId string, deviceId string, serviceId string, functionId string) {
 	if this == nil {
 		return
 	}
-	this.lastEventValueRequestCountVec.WithLabelValues(userId, deviceId, serviceId, functionId).Inc()
+	this.lastEventValueRequestCountVec.WithLabelValues(this.label(userId), this.label(deviceId), serviceId, functionId).Inc()
+}
+
+func (this *Metrics) LogResultCacheHit(userId string, deviceId string, serviceId string, functionId string) {
+	if this == nil {
+		return
+	}
+	this.resultCacheHitCountVec.WithLabelValues(this.label(userId), this.label(deviceId), serviceId, functionId).Inc()
+}
+
+func (this *Metrics) LogResultCacheMiss(userId string, deviceId string, serviceId string, functionId string) {
+	if this == nil {
+		return
+	}
+	this.resultCacheMissCountVec.WithLabelValues(this.label(userId), this.label(deviceId), serviceId, functionId).Inc()
+}
+
+func (this *Metrics) LogThrottled(userId string, scope string) {
+	if this == nil {
+		return
+	}
+	this.throttledCountVec.WithLabelValues(this.label(userId), scope).Inc()
 }
 
 func (this *Metrics) LogRequest(userId string, endpoint string) {
 	if this == nil {
 		return
 	}
-	this.requestsCountVec.WithLabelValues(userId, endpoint).Inc()
+	this.requestsCountVec.WithLabelValues(this.label(userId), endpoint).Inc()
+}
+
+// LogCommandResult records outcome and end-to-end latency of a device command
+func (this *Metrics) LogCommandResult(userId string, deviceId string, outcome string, latency time.Duration) {
+	if this == nil {
+		return
+	}
+	this.outcomeCountVec.WithLabelValues(this.label(userId), this.label(deviceId), outcome).Inc()
+	this.commandLatency.WithLabelValues(outcome).Observe(latency.Seconds())
+}
+
+func (this *Metrics) ObserveRegisterWait(latency time.Duration) {
+	if this == nil {
+		return
+	}
+	this.registerWaitLatency.Observe(latency.Seconds())
+}
+
+func (this *Metrics) ObserveIotRequest(method string, latency time.Duration) {
+	if this == nil {
+		return
+	}
+	this.iotLatency.WithLabelValues(method).Observe(latency.Seconds())
+}
+
+func (this *Metrics) ObserveTimescaleRequest(latency time.Duration) {
+	if this == nil {
+		return
+	}
+	this.timescaleLatency.Observe(latency.Seconds())
 }
@@ -0,0 +1,287 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/interfaces"
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
+	"github.com/SENERGY-Platform/external-task-worker/lib/marshaller"
+	"github.com/SENERGY-Platform/external-task-worker/lib/messages"
+	"github.com/SENERGY-Platform/models/go/models"
+	"github.com/golang-jwt/jwt"
+)
+
+const testSetFunctionId = "urn:infai:ses:controlling-function:set-value"
+const testGetFunctionId = "urn:infai:ses:measuring-function:get-value"
+
+// deviceMock simulates devices with a single value: set-value commands change it, get-value commands and last event values respond with it
+type deviceMock struct {
+	mux              sync.Mutex
+	values           map[string]interface{}
+	failing          map[string]bool          //devices responding with a device error
+	ignoringSet      map[string]bool          //devices acknowledging set-value commands without changing their value
+	delays           map[string]time.Duration //response delay by device; defaults to delay
+	delay            time.Duration
+	sent             []messages.ProtocolMsg
+	responseListener func(msg messages.ProtocolMsg) error
+	errorListener    func(msg messages.ProtocolMsg) error
+}
+
+func newDeviceMock() *deviceMock {
+	return &deviceMock{values: map[string]interface{}{}, failing: map[string]bool{}, ignoringSet: map[string]bool{}, delays: map[string]time.Duration{}}
+}
+
+func (this *deviceMock) ComFactory(ctx context.Context, config configuration.Config, responseListener func(msg messages.ProtocolMsg) error, errorListener func(msg messages.ProtocolMsg) error) (interfaces.Producer, error) {
+	this.responseListener = responseListener
+	this.errorListener = errorListener
+	return this, nil
+}
+
+func (this *deviceMock) SendCommand(ctx context.Context, msg messages.ProtocolMsg) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.sent = append(this.sent, msg)
+	deviceId := msg.Metadata.Device.Id
+	if this.failing[deviceId] {
+		msg.Response.Output = map[string]string{"error": "device failure"}
+		go this.respond(this.errorListener, msg, this.responseDelay(deviceId))
+		return nil
+	}
+	if input, ok := msg.Request.Input["value"]; ok && !this.ignoringSet[deviceId] {
+		var value interface{}
+		err := json.Unmarshal([]byte(input), &value)
+		if err != nil {
+			return err
+		}
+		this.values[deviceId] = value
+	}
+	output, err := json.Marshal(this.values[deviceId])
+	if err != nil {
+		return err
+	}
+	msg.Response.Output = map[string]string{"value": string(output)}
+	go this.respond(this.responseListener, msg, this.responseDelay(deviceId))
+	return nil
+}
+
+func (this *deviceMock) responseDelay(deviceId string) time.Duration {
+	if delay, ok := this.delays[deviceId]; ok {
+		return delay
+	}
+	return this.delay
+}
+
+func (this *deviceMock) respond(listener func(msg messages.ProtocolMsg) error, msg messages.ProtocolMsg, delay time.Duration) {
+	time.Sleep(delay)
+	listener(msg)
+}
+
+func (this *deviceMock) set(deviceId string, value interface{}) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.values[deviceId] = value
+}
+
+func (this *deviceMock) get(deviceId string) interface{} {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return this.values[deviceId]
+}
+
+func (this *deviceMock) sentCount() int {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return len(this.sent)
+}
+
+// sentTo returns the number of commands sent to deviceId with functionId
+func (this *deviceMock) sentTo(deviceId string, functionId string) (count int) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for _, msg := range this.sent {
+		if msg.Metadata.Device.Id != deviceId {
+			continue
+		}
+		_, isSet := msg.Request.Input["value"]
+		if isSet == (functionId == testSetFunctionId) {
+			count++
+		}
+	}
+	return count
+}
+
+// iotMock knows every device with a service for testSetFunctionId and testGetFunctionId; groups are listed in groups
+type iotMock struct {
+	groups map[string][]string
+}
+
+func (this iotMock) GetDevice(ctx context.Context, token string, id string) (model.Device, error) {
+	return model.Device{Id: id, DeviceTypeId: "device-type"}, nil
+}
+
+func (this iotMock) GetDeviceGroup(ctx context.Context, token string, id string) (model.DeviceGroup, error) {
+	deviceIds, ok := this.groups[id]
+	if !ok {
+		return model.DeviceGroup{}, errors.New("unknown group")
+	}
+	return model.DeviceGroup{Id: id, DeviceIds: deviceIds}, nil
+}
+
+func (this iotMock) GetService(ctx context.Context, token string, device model.Device, id string) (model.Service, error) {
+	result := testService()
+	result.Id = id
+	return result, nil
+}
+
+func (this iotMock) GetDeviceType(ctx context.Context, token string, id string) (model.DeviceType, error) {
+	return model.DeviceType{Id: id, Services: []model.Service{testService()}}, nil
+}
+
+// testService receives the input in the "value" segment and sends its output (also as event) in the "value" segment
+func testService() model.Service {
+	return model.Service{
+		Id:          "service",
+		ProtocolId:  "protocol",
+		Interaction: model.EVENT_AND_REQUEST,
+		Inputs:      []model.Content{{ProtocolSegmentId: "segment", Serialization: models.JSON, ContentVariable: model.ContentVariable{Name: "value", FunctionId: testSetFunctionId}}},
+		Outputs:     []model.Content{{ProtocolSegmentId: "segment", Serialization: models.JSON, ContentVariable: model.ContentVariable{Name: "value", FunctionId: testGetFunctionId}}},
+	}
+}
+
+func (this iotMock) GetProtocol(ctx context.Context, token string, id string) (model.Protocol, error) {
+	return model.Protocol{Id: id, Handler: "protocol", ProtocolSegments: []model.ProtocolSegment{{Id: "segment", Name: "value"}}}, nil
+}
+
+func (this iotMock) ListFunctions(ctx context.Context) ([]model.Function, error) {
+	set, _ := this.GetFunction(ctx, testSetFunctionId)
+	get, _ := this.GetFunction(ctx, testGetFunctionId)
+	return []model.Function{set, get}, nil
+}
+
+func (this iotMock) GetFunction(ctx context.Context, id string) (model.Function, error) {
+	if strings.HasPrefix(id, model.MEASURING_FUNCTION_PREFIX) {
+		return model.Function{Id: id, ConceptId: "concept"}, nil
+	}
+	return model.Function{Id: id, RdfType: model.SES_ONTOLOGY_CONTROLLING_FUNCTION, ConceptId: "concept"}, nil
+}
+
+func (this iotMock) GetConcept(ctx context.Context, id string) (model.Concept, error) {
+	return model.Concept{Id: id, BaseCharacteristicId: "characteristic"}, nil
+}
+
+func (this iotMock) GetCharacteristic(ctx context.Context, id string) (model.Characteristic, error) {
+	return model.Characteristic{Id: id}, nil
+}
+
+func (this iotMock) GetAspectNode(ctx context.Context, id string) (model.AspectNode, error) {
+	return model.AspectNode{Id: id}, nil
+}
+
+func (this iotMock) GetConceptIds(ctx context.Context) ([]string, error) {
+	return []string{"concept"}, nil
+}
+
+// marshallerMock sends the input as json in the "value" field of the protocol message
+type marshallerMock struct{}
+
+func (this marshallerMock) MarshalFromServiceAndProtocol(ctx context.Context, characteristicId string, service model.Service, protocol model.Protocol, characteristicData interface{}, configurables []marshaller.Configurable) (map[string]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (this marshallerMock) UnmarshalFromServiceAndProtocol(ctx context.Context, characteristicId string, service model.Service, protocol model.Protocol, message map[string]string, hints []string) (interface{}, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (this marshallerMock) MarshalV2(ctx context.Context, service model.Service, protocol model.Protocol, data []marshaller.MarshallingV2RequestData) (map[string]string, error) {
+	if len(data) == 0 {
+		return map[string]string{}, nil
+	}
+	temp, err := json.Marshal(data[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"value": string(temp)}, nil
+}
+
+func (this marshallerMock) UnmarshalV2(ctx context.Context, request marshaller.UnmarshallingV2Request) (result interface{}, err error) {
+	err = json.Unmarshal([]byte(request.Message["value"]), &result)
+	return result, err
+}
+
+// timescaleMock returns the current value of the device as last event
+type timescaleMock struct {
+	devices *deviceMock
+}
+
+func (this timescaleMock) GetLastMessage(ctx context.Context, token auth.Token, device models.Device, service models.Service, protocol model.Protocol) (map[string]interface{}, error) {
+	this.devices.mux.Lock()
+	defer this.devices.mux.Unlock()
+	value, ok := this.devices.values[device.Id]
+	if !ok {
+		return nil, interfaces.ErrMissingLastValue
+	}
+	return map[string]interface{}{"value": value}, nil
+}
+
+// newTestCommand creates a Command using devices and groups as mocks
+func newTestCommand(t *testing.T, ctx context.Context, devices *deviceMock, groups map[string][]string, modify func(config *configuration.Config)) *Command {
+	t.Helper()
+	config, err := configuration.Load("../../config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.ScheduleStorageDir = "-"
+	config.GroupRollingWaveDelay = "-"
+	if modify != nil {
+		modify(&config)
+	}
+	cmd, err := NewWithFactories(ctx, config, devices.ComFactory, func(ctx context.Context, config configuration.Config, iot interfaces.Iot) (interfaces.Marshaller, error) {
+		return marshallerMock{}, nil
+	}, func(ctx context.Context, config configuration.Config) (interfaces.Iot, error) {
+		return iotMock{groups: groups}, nil
+	}, func(ctx context.Context, config configuration.Config) (interfaces.Timescale, error) {
+		return timescaleMock{devices: devices}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cmd
+}
+
+func testToken(t *testing.T) auth.Token {
+	t.Helper()
+	claims := jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix(), Subject: "user"}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SigningString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := auth.Parse(unsigned + ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
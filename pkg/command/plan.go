@@ -0,0 +1,84 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/external-task-worker/lib/messages"
+)
+
+// PlanElement describes what a device command or a sub command of a group command would do
+type PlanElement struct {
+	DeviceId      string                `json:"device_id"`
+	ServiceId     string                `json:"service_id"`
+	StatusCode    int                   `json:"status_code"`
+	UseEventValue bool                  `json:"use_event_value"`   //the value would be read from the last event instead of sending message
+	Message       *messages.ProtocolMsg `json:"message,omitempty"` //the message that would be sent, without task id
+	Error         *CommandError         `json:"error,omitempty"`
+}
+
+// Plan resolves cmd like Command does, without sending messages or reading event values.
+// condition, return_previous and verify are not resolved; the plan describes the command itself.
+// responds with []PlanElement or a CommandError
+func (this *Command) Plan(ctx context.Context, token auth.Token, cmd CommandMessage, timeout string, preferEventValue bool) (code int, resp interface{}) {
+	ctx, cancel := this.withTimeout(ctx, timeout)
+	defer cancel()
+	if cmd.DeviceId != "" && cmd.ServiceId != "" {
+		return http.StatusOK, []PlanElement{this.planDeviceCommand(ctx, token, cmd.DeviceId, cmd.ServiceId, cmd.FunctionId, cmd.AspectId, cmd.Input, preferEventValue, cmd.CharacteristicId)}
+	}
+	if cmd.GroupId != "" {
+		subTasks, err := this.GetSubTasks(ctx, token.Jwt(), cmd.GroupId, cmd.FunctionId, cmd.AspectId, cmd.DeviceClassId, cmd.Input)
+		if err != nil {
+			return loadError(ctx, ErrCodeGroupLoad, err.Error(), AffectedIds{GroupId: cmd.GroupId, FunctionId: cmd.FunctionId, AspectId: cmd.AspectId})
+		}
+		result := []PlanElement{}
+		for _, sub := range subTasks {
+			result = append(result, this.planDeviceCommand(ctx, token, sub.DeviceId, sub.ServiceId, sub.FunctionId, sub.AspectId, cmd.Input, preferEventValue, cmd.CharacteristicId))
+		}
+		return http.StatusOK, result
+	}
+	return http.StatusBadRequest, NewCommandError(http.StatusBadRequest, ErrCodeInvalidRequest, "missing device_id, service_id or group_id", AffectedIds{FunctionId: cmd.FunctionId})
+}
+
+// PlanBatch plans each command of batch; the message of each result element is a []PlanElement
+func (this *Command) PlanBatch(ctx context.Context, token auth.Token, batch BatchRequest, timeout string, preferEventValue bool) []BatchResultElement {
+	ctx, cancel := this.withTimeout(ctx, timeout)
+	defer cancel()
+	result := []BatchResultElement{}
+	for _, cmd := range batch {
+		result = append(result, NewBatchResultElement(this.Plan(ctx, token, cmd, timeout, preferEventValue)))
+	}
+	return result
+}
+
+func (this *Command) planDeviceCommand(ctx context.Context, token auth.Token, deviceId string, serviceId string, functionId string, aspectId string, input interface{}, preferEventValue bool, characteristicId string) PlanElement {
+	result := PlanElement{DeviceId: deviceId, ServiceId: serviceId}
+	resolved, code, commandErr := this.resolveDeviceCommand(ctx, token, deviceId, serviceId, functionId, aspectId, input, preferEventValue, characteristicId)
+	result.StatusCode = code
+	if commandErr != nil {
+		result.Error = commandErr
+		return result
+	}
+	result.UseEventValue = resolved.useEventValue
+	if !resolved.useEventValue {
+		result.Message = &resolved.message
+	}
+	return result
+}
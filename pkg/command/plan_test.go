@@ -0,0 +1,90 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestPlanSendsNothing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := newDeviceMock()
+	devices.set("lamp1", 1.0)
+	devices.set("lamp2", 1.0)
+	cmd := newTestCommand(t, ctx, devices, map[string][]string{"group": {"lamp1", "lamp2"}}, nil)
+	token := testToken(t)
+
+	t.Run("device", func(t *testing.T) {
+		code, resp := cmd.Plan(ctx, token, CommandMessage{FunctionId: testSetFunctionId, DeviceId: "lamp1", ServiceId: "service", Input: 5}, "5s", false)
+		plan, ok := resp.([]PlanElement)
+		if code != http.StatusOK || !ok || len(plan) != 1 {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		element := plan[0]
+		if element.DeviceId != "lamp1" || element.ServiceId != "service" || element.StatusCode != http.StatusOK || element.Error != nil || element.UseEventValue {
+			t.Errorf("%#v", element)
+		}
+		if element.Message == nil || element.Message.Request.Input["value"] != "5" || element.Message.Metadata.Device.Id != "lamp1" || element.Message.TaskInfo.TaskId != "" {
+			t.Errorf("%#v", element.Message)
+		}
+	})
+
+	t.Run("event value", func(t *testing.T) {
+		code, resp := cmd.Plan(ctx, token, CommandMessage{FunctionId: testGetFunctionId, DeviceId: "lamp1", ServiceId: "service"}, "5s", true)
+		plan, ok := resp.([]PlanElement)
+		if code != http.StatusOK || !ok || len(plan) != 1 {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if !plan[0].UseEventValue || plan[0].Message != nil {
+			t.Errorf("%#v", plan[0])
+		}
+	})
+
+	t.Run("group", func(t *testing.T) {
+		code, resp := cmd.Plan(ctx, token, CommandMessage{FunctionId: testSetFunctionId, GroupId: "group", Input: 5}, "5s", false)
+		plan, ok := resp.([]PlanElement)
+		if code != http.StatusOK || !ok || len(plan) != 2 {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		for i, deviceId := range []string{"lamp1", "lamp2"} {
+			if plan[i].DeviceId != deviceId || plan[i].Message == nil {
+				t.Errorf("%v %#v", i, plan[i])
+			}
+		}
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		code, resp := cmd.Plan(ctx, token, CommandMessage{FunctionId: testSetFunctionId, GroupId: "unknown", Input: 5}, "5s", false)
+		commandErr, ok := resp.(*CommandError)
+		if code == http.StatusOK || !ok || commandErr.ErrorCode != ErrCodeGroupLoad {
+			t.Errorf("%v %#v", code, resp)
+		}
+	})
+
+	if sent := devices.sentCount(); sent != 0 {
+		t.Error(sent)
+	}
+	if devices.get("lamp1") != 1.0 || devices.get("lamp2") != 1.0 {
+		t.Error(devices.get("lamp1"), devices.get("lamp2"))
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
+)
+
+var errNotControllingFunction = errors.New("expect a controlling function")
+
+// PreviousResult is the response of a command with return_previous
+type PreviousResult struct {
+	Previous      interface{} `json:"previous"`
+	PreviousError string      `json:"previous_error,omitempty"` //set if the previous value could not be read; the command is sent anyway
+	Result        interface{} `json:"result"`
+}
+
+// commandWithPrevious reads the current value before cmd is sent; failed commands respond with their error instead of a PreviousResult
+func (this *Command) commandWithPrevious(ctx context.Context, token auth.Token, cmd CommandMessage, timeout string, preferEventValue bool, asyncTaskId string) (code int, resp interface{}) {
+	cmd.ReturnPrevious = false
+	previous, err := this.readCurrentValue(ctx, token, cmd, timeout, preferEventValue)
+	if errors.Is(err, errNotControllingFunction) {
+		return http.StatusBadRequest, NewCommandError(http.StatusBadRequest, ErrCodeInvalidRequest, "return_previous: "+err.Error(), AffectedIds{DeviceId: cmd.DeviceId, ServiceId: cmd.ServiceId, FunctionId: cmd.FunctionId})
+	}
+	result := PreviousResult{Previous: previous}
+	if err != nil {
+		result.PreviousError = err.Error()
+	}
+	code, resp = this.command(ctx, token, cmd, timeout, preferEventValue, asyncTaskId)
+	if code != http.StatusOK {
+		return code, resp
+	}
+	result.Result = resp
+	return code, result
+}
+
+// readCurrentValue reads the current value through a measuring function with the same concept and aspect as the controlling function of cmd
+func (this *Command) readCurrentValue(ctx context.Context, token auth.Token, cmd CommandMessage, timeout string, preferEventValue bool) (result interface{}, err error) {
+	function, err := this.iot.GetFunction(ctx, cmd.FunctionId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load function: %w", err)
+	}
+	if !isControllingFunction(function) {
+		return nil, errNotControllingFunction
+	}
+	if function.ConceptId == "" {
+		return nil, errors.New("controlling function has no concept")
+	}
+	device, err := this.iot.GetDevice(ctx, token.Jwt(), cmd.DeviceId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load device: %w", err)
+	}
+	deviceType, err := this.iot.GetDeviceType(ctx, token.Jwt(), device.DeviceTypeId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load device type: %w", err)
+	}
+	aspectIds := []string{}
+	if cmd.AspectId != "" {
+		aspect, err := this.iot.GetAspectNode(ctx, cmd.AspectId)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load aspect node: %w", err)
+		}
+		aspectIds = append([]string{aspect.Id}, aspect.DescendentIds...)
+	}
+	//functions are only loaded for measuring function ids used by the device type; GetFunction is cached
+	var loadErr error
+	sameConcept := map[string]bool{}
+	isMatchingFunction := func(functionId string) bool {
+		if !isMeasuringFunctionId(functionId) {
+			return false
+		}
+		matches, known := sameConcept[functionId]
+		if !known {
+			f, err := this.iot.GetFunction(ctx, functionId)
+			if err != nil {
+				loadErr = err
+				return false
+			}
+			matches = f.ConceptId == function.ConceptId
+			sameConcept[functionId] = matches
+		}
+		return matches
+	}
+	serviceId, functionId, found := findMeasuringService(deviceType, isMatchingFunction, aspectIds)
+	if !found && loadErr != nil {
+		return nil, fmt.Errorf("unable to load function: %w", loadErr)
+	}
+	if !found {
+		return nil, errors.New("no matching measuring service found")
+	}
+	code, resp := this.deviceCommand(ctx, token, device.Id, serviceId, functionId, cmd.AspectId, nil, timeout, preferEventValue, cmd.CharacteristicId, "")
+	if code != http.StatusOK {
+		return nil, errors.New("unable to read current value: " + errorDetail(resp))
+	}
+	return resp, nil
+}
+
+func findMeasuringService(deviceType model.DeviceType, isMatchingFunction func(functionId string) bool, aspectIds []string) (serviceId string, functionId string, found bool) {
+	for _, service := range deviceType.Services {
+		for _, output := range service.Outputs {
+			functionId, found = findMeasuringVariable(output.ContentVariable, isMatchingFunction, aspectIds)
+			if found {
+				return service.Id, functionId, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func findMeasuringVariable(variable model.ContentVariable, isMatchingFunction func(functionId string) bool, aspectIds []string) (functionId string, found bool) {
+	if (len(aspectIds) == 0 || slices.Contains(aspectIds, variable.AspectId)) && isMatchingFunction(variable.FunctionId) {
+		return variable.FunctionId, true
+	}
+	for _, sub := range variable.SubContentVariables {
+		functionId, found = findMeasuringVariable(sub, isMatchingFunction, aspectIds)
+		if found {
+			return functionId, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestCommandWithPrevious(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := newDeviceMock()
+	devices.set("device", 1.0)
+	devices.failing["broken"] = true
+	cmd := newTestCommand(t, ctx, devices, nil, nil)
+	token := testToken(t)
+
+	t.Run("success", func(t *testing.T) {
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, DeviceId: "device", ServiceId: "service", Input: 2, ReturnPrevious: true}, "5s", false)
+		result, ok := resp.(PreviousResult)
+		if code != http.StatusOK || !ok {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if result.PreviousError != "" || result.Previous != 1.0 {
+			t.Errorf("%#v", result)
+		}
+		if value := devices.get("device"); value != 2.0 {
+			t.Error(value)
+		}
+	})
+
+	t.Run("failure returns the bare error", func(t *testing.T) {
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, DeviceId: "broken", ServiceId: "service", Input: 2, ReturnPrevious: true}, "5s", false)
+		commandErr, ok := resp.(*CommandError)
+		if code != http.StatusInternalServerError || !ok || commandErr.ErrorCode != ErrCodeDeviceError {
+			t.Errorf("%v %#v", code, resp)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/ratelimit"
+)
+
+const (
+	RateLimitScopeUser          = "user"
+	RateLimitScopeDevice        = "device"
+	RateLimitScopeDeviceService = "device_service"
+)
+
+// checkRateLimit is called for every command sent to a device, including sub commands of groups and batches
+func (this *Command) checkRateLimit(token auth.Token, deviceId string, serviceId string, ids AffectedIds) (code int, commandErr *CommandError) {
+	ok, scope, retryAfter := this.limiter.Allow(
+		ratelimit.Key{Scope: RateLimitScopeUser, Id: token.GetUserId()},
+		ratelimit.Key{Scope: RateLimitScopeDevice, Id: deviceId},
+		ratelimit.Key{Scope: RateLimitScopeDeviceService, Id: deviceId + "/" + serviceId},
+	)
+	if ok {
+		return http.StatusOK, nil
+	}
+	this.metrics.LogThrottled(token.GetUserId(), scope)
+	commandErr = NewCommandError(http.StatusTooManyRequests, ErrCodeRateLimited, fmt.Sprintf("%v rate limit exceeded", scope), ids)
+	commandErr.RetryAfter = int64(math.Ceil(retryAfter.Seconds()))
+	return http.StatusTooManyRequests, commandErr
+}
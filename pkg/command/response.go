@@ -17,20 +17,39 @@
 package command
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/SENERGY-Platform/device-command/pkg/scaling"
+	"github.com/SENERGY-Platform/device-command/pkg/tracing"
 	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
 	"github.com/SENERGY-Platform/external-task-worker/lib/marshaller"
 	"github.com/SENERGY-Platform/external-task-worker/lib/messages"
-	"net/http"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 func (this *Command) HandleTaskResponse(message messages.ProtocolMsg) (err error) {
+	if this.forwardToOwner(scaling.ResponsePath, message) {
+		return nil
+	}
+	return this.HandleLocalTaskResponse(message)
+}
+
+// HandleLocalTaskResponse handles the response without checking which replica waits for it
+func (this *Command) HandleLocalTaskResponse(message messages.ProtocolMsg) (err error) {
+	ctx, span := tracing.Start(this.traces.Context(context.Background(), message.TaskInfo.TaskId), "unmarshal", attribute.String("task_id", message.TaskInfo.TaskId))
+	defer func() { tracing.End(span, err) }()
 	var output interface{}
 	aspect := model.AspectNode{}
 	if message.Metadata.OutputAspectNode != nil {
 		aspect = *message.Metadata.OutputAspectNode
 	}
 	if message.Metadata.OutputCharacteristic != model.NullCharacteristic.Id && message.Metadata.OutputCharacteristic != "" {
-		output, err = this.marshaller.UnmarshalV2(marshaller.UnmarshallingV2Request{
+		output, err = this.marshaller.UnmarshalV2(ctx, marshaller.UnmarshallingV2Request{
 			Service:          message.Metadata.Service,
 			Protocol:         message.Metadata.Protocol,
 			CharacteristicId: message.Metadata.OutputCharacteristic,
@@ -40,7 +59,9 @@ func (this *Command) HandleTaskResponse(message messages.ProtocolMsg) (err error
 			AspectNodeId:     aspect.Id,
 		})
 		if err != nil {
-			this.register.Complete(message.TaskInfo.TaskId, http.StatusInternalServerError, err.Error())
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			this.register.Complete(message.TaskInfo.TaskId, http.StatusInternalServerError, NewCommandError(http.StatusInternalServerError, ErrCodeUnmarshal, err.Error(), AffectedIds{}))
 			return nil
 		}
 	}
@@ -49,6 +70,44 @@ func (this *Command) HandleTaskResponse(message messages.ProtocolMsg) (err error
 }
 
 func (this *Command) ErrorMessageHandler(message messages.ProtocolMsg) error {
-	this.register.Complete(message.TaskInfo.TaskId, http.StatusInternalServerError, message.Response.Output)
+	if this.forwardToOwner(scaling.ErrorPath, message) {
+		return nil
+	}
+	return this.HandleLocalErrorMessage(message)
+}
+
+// HandleLocalErrorMessage handles the error without checking which replica waits for it
+func (this *Command) HandleLocalErrorMessage(message messages.ProtocolMsg) error {
+	_, span := tracing.Start(this.traces.Context(context.Background(), message.TaskInfo.TaskId), "device_error", attribute.String("task_id", message.TaskInfo.TaskId))
+	span.SetStatus(codes.Error, fmt.Sprint(message.Response.Output))
+	defer span.End()
+	commandErr := NewCommandError(http.StatusInternalServerError, ErrCodeDeviceError, fmt.Sprint(message.Response.Output), AffectedIds{})
+	commandErr.DeviceOutput = message.Response.Output
+	this.register.Complete(message.TaskInfo.TaskId, http.StatusInternalServerError, commandErr)
 	return nil
 }
+
+// forwardToOwner sends the message to the replica waiting for it, if this is not the current replica
+func (this *Command) forwardToOwner(path string, message messages.ProtocolMsg) (forwarded bool) {
+	owner, local := this.router.Owner(message.TaskInfo.TaskId)
+	if local {
+		return false
+	}
+	if this.config.Debug {
+		log.Println("DEBUG: forward", path, message.TaskInfo.TaskId, "to", owner)
+	}
+	temp, err := json.Marshal(message)
+	if err != nil {
+		log.Println("ERROR: unable to marshal message for forwarding", err)
+		return true
+	}
+	err = this.router.Forward(owner, path, temp)
+	if err != nil {
+		log.Println("ERROR: drop", path, message.TaskInfo.TaskId, "for", owner, err)
+	}
+	return true
+}
+
+func (this *Command) ValidForwardSecret(secret string) bool {
+	return this.router.ValidSecret(secret)
+}
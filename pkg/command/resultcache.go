@@ -0,0 +1,165 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/status"
+)
+
+type maxAgeCtxKey struct{}
+
+// withMaxAge lets measuring device commands using ctx reuse results which are not older than maxAge
+func withMaxAge(ctx context.Context, maxAge time.Duration) context.Context {
+	return context.WithValue(ctx, maxAgeCtxKey{}, maxAge)
+}
+
+func getMaxAge(ctx context.Context) time.Duration {
+	maxAge, _ := ctx.Value(maxAgeCtxKey{}).(time.Duration)
+	return maxAge
+}
+
+// resultCache keeps successful results of measuring device commands and coalesces concurrent identical requests
+type resultCache struct {
+	entries map[string]*cachedResult
+	mux     sync.Mutex
+	maxAge  time.Duration
+}
+
+type cachedResult struct {
+	done        chan struct{}
+	code        int
+	resp        interface{}
+	completedAt time.Time
+}
+
+// newResultCache creates a cache which limits every requested max age to maxAge
+func newResultCache(ctx context.Context, maxAge time.Duration) *resultCache {
+	result := &resultCache{entries: map[string]*cachedResult{}, maxAge: maxAge}
+	go result.cleanupLoop(ctx)
+	return result
+}
+
+// get returns the successful result of key if it is not older than maxAge or waits for the running request of key.
+// otherwise f is called and its result is shared with concurrent and following requests.
+// results caused by the context of the running request (408, 499) are not shared; waiting requests call f themselves.
+// hit is true if the result was not produced by this call of f.
+func (this *resultCache) get(ctx context.Context, key string, maxAge time.Duration, ids AffectedIds, f func() (code int, resp interface{})) (code int, resp interface{}, hit bool) {
+	maxAge = min(maxAge, this.maxAge)
+	for {
+		this.mux.Lock()
+		entry, ok := this.entries[key]
+		if ok && !entry.completedAt.IsZero() && (entry.code != http.StatusOK || time.Since(entry.completedAt) > maxAge) {
+			ok = false
+		}
+		if !ok {
+			entry = &cachedResult{done: make(chan struct{})}
+			this.entries[key] = entry
+		}
+		this.mux.Unlock()
+
+		if !ok {
+			code, resp = f()
+			this.mux.Lock()
+			entry.code = code
+			entry.resp = resp
+			entry.completedAt = time.Now()
+			this.mux.Unlock()
+			close(entry.done)
+			return code, resp, false
+		}
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			code = http.StatusRequestTimeout
+			if errors.Is(ctx.Err(), context.Canceled) {
+				code = status.ClientClosedRequest
+			}
+			return code, completeResponseError(code, "stopped while waiting for identical request", ids), true
+		}
+		//the running request was canceled or timed out by its own caller; retry as long as this request is still valid
+		if (entry.code == status.ClientClosedRequest || entry.code == http.StatusRequestTimeout) && ctx.Err() == nil {
+			continue
+		}
+		return entry.code, copyCommandError(entry.resp), true
+	}
+}
+
+// copyCommandError returns a copy of resp if it is a *CommandError, so that callers sharing a result may change their error
+func copyCommandError(resp interface{}) interface{} {
+	if commandErr, ok := resp.(*CommandError); ok && commandErr != nil {
+		temp := *commandErr
+		return &temp
+	}
+	return resp
+}
+
+func (this *resultCache) cleanupLoop(ctx context.Context) {
+	interval := this.maxAge
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			this.cleanup()
+		}
+	}
+}
+
+func (this *resultCache) cleanup() {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for key, entry := range this.entries {
+		if !entry.completedAt.IsZero() && time.Since(entry.completedAt) > this.maxAge {
+			delete(this.entries, key)
+		}
+	}
+}
+
+// cachedDeviceCommand serves measuring device commands from the result cache.
+// the device is loaded with the token of the caller to ensure that cached results are only returned to users with access to the device.
+func (this *Command) cachedDeviceCommand(ctx context.Context, maxAge time.Duration, token auth.Token, deviceId string, serviceId string, functionId string, aspectId string, preferEventValue bool, characteristicId string, asyncTaskId string) (code int, resp interface{}) {
+	ids := AffectedIds{DeviceId: deviceId, ServiceId: serviceId, FunctionId: functionId, AspectId: aspectId}
+	_, err := this.iot.GetDevice(ctx, token.Jwt(), deviceId)
+	if err != nil {
+		return loadError(ctx, ErrCodeDeviceLoad, "unable to load device: "+err.Error(), ids)
+	}
+	key := strings.Join([]string{deviceId, serviceId, functionId, aspectId, characteristicId, strconv.FormatBool(preferEventValue)}, "/")
+	code, resp, hit := this.resultCache.get(ctx, key, maxAge, ids, func() (int, interface{}) {
+		return this.runDeviceCommand(ctx, token, deviceId, serviceId, functionId, aspectId, nil, preferEventValue, characteristicId, asyncTaskId)
+	})
+	if hit {
+		this.metrics.LogResultCacheHit(token.GetUserId(), deviceId, serviceId, functionId)
+	} else {
+		this.metrics.LogResultCacheMiss(token.GetUserId(), deviceId, serviceId, functionId)
+	}
+	return code, resp
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/status"
+)
+
+func TestResultCacheCoalescesConcurrentRequests(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache := newResultCache(ctx, time.Minute)
+
+	calls := atomic.Int64{}
+	hits := atomic.Int64{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, resp, hit := cache.get(ctx, "key", time.Minute, AffectedIds{}, func() (int, interface{}) {
+				calls.Add(1)
+				time.Sleep(100 * time.Millisecond)
+				return http.StatusOK, 42
+			})
+			if code != http.StatusOK || resp != 42 {
+				t.Error(code, resp)
+			}
+			if hit {
+				hits.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 || hits.Load() != 19 {
+		t.Error(calls.Load(), hits.Load())
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	_, _, hit := cache.get(ctx, "key", 50*time.Millisecond, AffectedIds{}, func() (int, interface{}) {
+		calls.Add(1)
+		return http.StatusOK, 13
+	})
+	if hit || calls.Load() != 2 {
+		t.Error("expected new request for result older than max age", hit, calls.Load())
+	}
+}
+
+func TestResultCacheCopiesErrorsForJoiners(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache := newResultCache(ctx, time.Minute)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leaderErr := NewCommandError(http.StatusInternalServerError, ErrCodeDeviceError, "device failure", AffectedIds{DeviceId: "device"})
+	go cache.get(ctx, "key", time.Minute, AffectedIds{}, func() (int, interface{}) {
+		close(started)
+		<-release
+		return http.StatusInternalServerError, leaderErr
+	})
+	<-started
+
+	results := make([]interface{}, 10)
+	wg := sync.WaitGroup{}
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, resp, _ := cache.get(ctx, "key", time.Minute, AffectedIds{}, func() (int, interface{}) {
+				return http.StatusOK, "executed again"
+			})
+			if code != http.StatusInternalServerError {
+				t.Error(code, resp)
+				return
+			}
+			resp.(*CommandError).Detail = "changed by " + strconv.Itoa(i)
+			results[i] = resp
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	seen := map[*CommandError]bool{leaderErr: true}
+	for _, result := range results {
+		commandErr, ok := result.(*CommandError)
+		if !ok || seen[commandErr] {
+			t.Errorf("expected own copy of the error %#v", result)
+			continue
+		}
+		seen[commandErr] = true
+	}
+	if leaderErr.Detail != "device failure" {
+		t.Error(leaderErr.Detail)
+	}
+}
+
+func TestResultCacheDoesNotShareContextErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache := newResultCache(ctx, time.Minute)
+
+	for _, leaderCode := range []int{status.ClientClosedRequest, http.StatusRequestTimeout} {
+		key := strconv.Itoa(leaderCode)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		go cache.get(ctx, key, time.Minute, AffectedIds{}, func() (int, interface{}) {
+			close(started)
+			<-release
+			return leaderCode, "stopped by leader context"
+		})
+		<-started
+		time.AfterFunc(100*time.Millisecond, func() { close(release) })
+		code, resp, hit := cache.get(ctx, key, time.Minute, AffectedIds{}, func() (int, interface{}) {
+			return http.StatusOK, "own result"
+		})
+		if code != http.StatusOK || resp != "own result" || hit {
+			t.Error(leaderCode, code, resp, hit)
+		}
+	}
+}
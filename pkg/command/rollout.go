@@ -0,0 +1,174 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	RolloutParallel   = "parallel"
+	RolloutSequential = "sequential"
+	RolloutRolling    = "rolling"
+)
+
+// Rollout controls how the sub commands of a group or the commands of a batch are started.
+// unset fields use the group_* config values
+type Rollout struct {
+	Mode               string `json:"mode,omitempty"`                 //"parallel" || "sequential" || "rolling"
+	MaxConcurrency     int    `json:"max_concurrency,omitempty"`      //parallel: max number of running commands; 0 is unlimited
+	WaveSize           int    `json:"wave_size,omitempty"`            //rolling: number of commands started together
+	WaveDelay          string `json:"wave_delay,omitempty"`           //rolling: pause after each wave
+	AbortAfterFailures int    `json:"abort_after_failures,omitempty"` //commands not started yet are skipped after this many failures; 0 never aborts
+}
+
+func (this Rollout) Validate() error {
+	switch this.Mode {
+	case "", RolloutParallel, RolloutSequential, RolloutRolling:
+	default:
+		return errors.New("unknown rollout mode " + this.Mode)
+	}
+	if this.MaxConcurrency < 0 || this.WaveSize < 0 || this.AbortAfterFailures < 0 {
+		return errors.New("rollout values must not be negative")
+	}
+	if this.WaveDelay != "" {
+		delay, err := time.ParseDuration(this.WaveDelay)
+		if err != nil {
+			return err
+		}
+		if delay < 0 {
+			return errors.New("rollout wave_delay must not be negative")
+		}
+	}
+	return nil
+}
+
+// getRollout completes rollout with the config defaults
+func (this *Command) getRollout(rollout *Rollout) (result Rollout) {
+	if rollout != nil {
+		result = *rollout
+	}
+	if result.Mode == "" {
+		result.Mode = this.config.GroupScheduler
+	}
+	if result.Mode != RolloutSequential && result.Mode != RolloutRolling {
+		result.Mode = RolloutParallel
+	}
+	if result.MaxConcurrency == 0 {
+		result.MaxConcurrency = int(this.config.GroupMaxConcurrency)
+	}
+	if result.WaveSize == 0 {
+		result.WaveSize = int(this.config.GroupRollingWaveSize)
+	}
+	if result.WaveSize <= 0 {
+		result.WaveSize = 10
+	}
+	if result.WaveDelay == "" && this.config.GroupRollingWaveDelay != "-" {
+		result.WaveDelay = this.config.GroupRollingWaveDelay
+	}
+	if result.AbortAfterFailures == 0 {
+		result.AbortAfterFailures = int(this.config.GroupAbortAfterFailures)
+	}
+	return result
+}
+
+// runRollout calls run for the indexes 0 to count-1 as defined by rollout; run reports if the command succeeded.
+// the returned list marks indexes which were skipped because ctx is done or too many commands failed
+func runRollout(ctx context.Context, rollout Rollout, count int, run func(index int) (success bool)) (skipped []bool) {
+	skipped = make([]bool, count)
+	mux := sync.Mutex{}
+	failures := 0
+	aborted := func() bool {
+		mux.Lock()
+		defer mux.Unlock()
+		return ctx.Err() != nil || (rollout.AbortAfterFailures > 0 && failures >= rollout.AbortAfterFailures)
+	}
+	wg := sync.WaitGroup{}
+	start := func(index int, limit chan struct{}) {
+		acquired := false
+		if limit != nil {
+			select {
+			case limit <- struct{}{}:
+				acquired = true
+			case <-ctx.Done():
+			}
+		}
+		if aborted() {
+			skipped[index] = true
+			if acquired {
+				<-limit
+			}
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if acquired {
+				defer func() { <-limit }()
+			}
+			if !run(index) {
+				mux.Lock()
+				failures++
+				mux.Unlock()
+			}
+		}()
+	}
+
+	switch rollout.Mode {
+	case RolloutSequential:
+		for i := 0; i < count; i++ {
+			start(i, nil)
+			wg.Wait()
+		}
+	case RolloutRolling:
+		delay, _ := time.ParseDuration(rollout.WaveDelay)
+		for waveStart := 0; waveStart < count; waveStart = waveStart + rollout.WaveSize {
+			if waveStart > 0 && delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+				}
+			}
+			for i := waveStart; i < min(waveStart+rollout.WaveSize, count); i++ {
+				start(i, nil)
+			}
+			wg.Wait()
+		}
+	default:
+		var limit chan struct{}
+		if rollout.MaxConcurrency > 0 {
+			limit = make(chan struct{}, rollout.MaxConcurrency)
+		}
+		for i := 0; i < count; i++ {
+			start(i, limit)
+		}
+		wg.Wait()
+	}
+	return skipped
+}
+
+// skippedError describes why runRollout skipped a command
+func skippedError(ctx context.Context, ids AffectedIds) (int, *CommandError) {
+	if ctx.Err() != nil {
+		return loadError(ctx, ErrCodeRolloutAborted, "command not sent: "+ctx.Err().Error(), ids)
+	}
+	return http.StatusFailedDependency, NewCommandError(http.StatusFailedDependency, ErrCodeRolloutAborted, "command not sent: rollout aborted after too many failures", ids)
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGroupRolloutAbortsAfterFailures(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := newDeviceMock()
+	devices.failing["broken1"] = true
+	devices.failing["broken2"] = true
+	groupDevices := []string{"broken1", "broken2", "lamp1", "lamp2", "lamp3"}
+	cmd := newTestCommand(t, ctx, devices, map[string][]string{"group": groupDevices}, nil)
+	token := testToken(t)
+
+	for _, rollout := range []Rollout{
+		{Mode: RolloutSequential, AbortAfterFailures: 2},
+		{Mode: RolloutRolling, WaveSize: 2, AbortAfterFailures: 2},
+		{Mode: RolloutParallel, MaxConcurrency: 1, AbortAfterFailures: 2},
+	} {
+		t.Run(rollout.Mode, func(t *testing.T) {
+			before := devices.sentCount()
+			code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, GroupId: "group", Input: 1, GroupResultDetails: true, Rollout: &rollout}, "5s", false)
+			result, ok := resp.(GroupResult)
+			if code == http.StatusOK || !ok {
+				t.Errorf("%v %#v", code, resp)
+				return
+			}
+			if sent := devices.sentCount() - before; sent != 2 {
+				t.Error(sent)
+			}
+			if result.Succeeded != 0 || result.Failed != len(groupDevices) {
+				t.Errorf("%#v", result)
+			}
+			for i, sub := range result.Results {
+				if sub.DeviceId != groupDevices[i] || sub.Error == nil {
+					t.Errorf("%v %#v", i, sub)
+					continue
+				}
+				if i < 2 && sub.Error.ErrorCode != ErrCodeDeviceError {
+					t.Errorf("%v %#v", i, sub.Error)
+				}
+				if i >= 2 && (sub.StatusCode != http.StatusFailedDependency || sub.Error.ErrorCode != ErrCodeRolloutAborted) {
+					t.Errorf("%v %#v", i, sub.Error)
+				}
+			}
+		})
+	}
+
+	t.Run("below threshold", func(t *testing.T) {
+		before := devices.sentCount()
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, GroupId: "group", Input: 1, GroupResultDetails: true, Rollout: &Rollout{Mode: RolloutSequential, AbortAfterFailures: 3}}, "5s", false)
+		result, ok := resp.(GroupResult)
+		if code != http.StatusOK || !ok {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if sent := devices.sentCount() - before; sent != len(groupDevices) {
+			t.Error(sent)
+		}
+		if result.Succeeded != 3 || result.Failed != 2 {
+			t.Errorf("%#v", result)
+		}
+	})
+}
@@ -0,0 +1,341 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/device-command/pkg/cron"
+	"github.com/SENERGY-Platform/device-command/pkg/storage"
+	"github.com/google/uuid"
+)
+
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// ErrScheduleTokenExchangeRequired is returned for cron schedules if no token exchange client is configured;
+// the token of the creating request would expire before later executions
+var ErrScheduleTokenExchangeRequired = errors.New("cron schedules need token exchange (auth_endpoint, auth_client_id, schedule_auth_client_secret)")
+
+// ErrScheduleTokenExpiresBeforeRun is returned for run_at schedules without token exchange if run_at is after the expiration of the creating token
+var ErrScheduleTokenExpiresBeforeRun = errors.New("run_at is after the expiration of the request token; configure schedule_auth_client_secret for token exchange")
+
+// errScheduleTokenLost is recorded for run_at schedules without token exchange whose token was lost by a restart
+var errScheduleTokenLost = errors.New("token of schedule lost by service restart; configure schedule_auth_client_secret")
+
+type ScheduleRequest struct {
+	Command *CommandMessage `json:"command,omitempty"` //either command or batch
+	Batch   BatchRequest    `json:"batch,omitempty"`
+
+	RunAt *time.Time `json:"run_at,omitempty"` //either run_at or cron
+	Cron  string     `json:"cron,omitempty"`   //"minute hour day-of-month month day-of-week", evaluated in UTC
+
+	Timeout          string `json:"timeout,omitempty"`
+	PreferEventValue bool   `json:"prefer_event_value,omitempty"`
+}
+
+func (this ScheduleRequest) Validate() error {
+	if (this.Command == nil) == (len(this.Batch) == 0) {
+		return errors.New("expect either command or batch")
+	}
+	if this.Command != nil {
+		err := this.Command.Validate()
+		if err != nil {
+			return err
+		}
+	} else {
+		err := this.Batch.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	if (this.RunAt == nil) == (this.Cron == "") {
+		return errors.New("expect either run_at or cron")
+	}
+	if this.RunAt != nil && this.RunAt.Before(time.Now()) {
+		return errors.New("run_at must be in the future")
+	}
+	if this.Cron != "" {
+		_, err := cron.Parse(this.Cron)
+		if err != nil {
+			return err
+		}
+	}
+	if this.Timeout != "" {
+		_, err := time.ParseDuration(this.Timeout)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Schedule struct {
+	Id string `json:"id"`
+	ScheduleRequest
+	CreatedAt time.Time           `json:"created_at"`
+	NextRun   *time.Time          `json:"next_run,omitempty"` //nil if a run_at schedule has been executed
+	History   []ScheduleExecution `json:"history"`            //most recent last
+}
+
+type ScheduleExecution struct {
+	Time    time.Time            `json:"time"`
+	Results []BatchResultElement `json:"results"`
+}
+
+// storedSchedule is persisted without token; tokens are requested by token exchange on execution
+type storedSchedule struct {
+	Schedule
+	UserId string `json:"user_id"`
+	token  string //token of the creating request; only kept in memory for run_at schedules without token exchange
+}
+
+// scheduler executes stored schedules as their owner
+type scheduler struct {
+	schedules   map[string]*storedSchedule
+	running     map[string]bool
+	mux         sync.Mutex
+	backend     storage.Backend[storedSchedule]
+	historySize int
+	config      configuration.Config
+}
+
+func newScheduler(config configuration.Config) (*scheduler, error) {
+	backend, err := storage.New[storedSchedule](config.ScheduleStorageDir)
+	if err != nil {
+		return nil, err
+	}
+	historySize := int(config.ScheduleHistorySize)
+	if historySize <= 0 {
+		historySize = 10
+	}
+	result := &scheduler{
+		schedules:   map[string]*storedSchedule{},
+		running:     map[string]bool{},
+		backend:     backend,
+		historySize: historySize,
+		config:      config,
+	}
+	stored, err := backend.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, element := range stored {
+		schedule := element
+		result.schedules[schedule.Id] = &schedule
+	}
+	return result, nil
+}
+
+func (this *Command) CreateSchedule(token auth.Token, request ScheduleRequest) (result Schedule, err error) {
+	err = request.Validate()
+	if err != nil {
+		return result, err
+	}
+	if request.Cron != "" && !this.scheduleTokenExchangeEnabled() {
+		return result, ErrScheduleTokenExchangeRequired
+	}
+	if request.RunAt != nil && !this.scheduleTokenExchangeEnabled() {
+		expiration, ok, err := auth.GetExpiration(token)
+		if err != nil {
+			return result, err
+		}
+		if ok && request.RunAt.After(expiration) {
+			return result, ErrScheduleTokenExpiresBeforeRun
+		}
+	}
+	now := time.Now()
+	result = Schedule{
+		Id:              uuid.NewString(),
+		ScheduleRequest: request,
+		CreatedAt:       now,
+		History:         []ScheduleExecution{},
+	}
+	result.NextRun = nextRun(request, now)
+	stored := &storedSchedule{Schedule: result, UserId: token.GetUserId()}
+	if !this.scheduleTokenExchangeEnabled() {
+		stored.token = token.Jwt()
+	}
+	this.scheduler.mux.Lock()
+	defer this.scheduler.mux.Unlock()
+	err = this.scheduler.backend.Set(result.Id, *stored)
+	if err != nil {
+		return result, err
+	}
+	this.scheduler.schedules[result.Id] = stored
+	return result, nil
+}
+
+func (this *Command) ListSchedules(token auth.Token) (result []Schedule) {
+	this.scheduler.mux.Lock()
+	defer this.scheduler.mux.Unlock()
+	result = []Schedule{}
+	for _, schedule := range this.scheduler.schedules {
+		if schedule.UserId == token.GetUserId() {
+			result = append(result, copySchedule(schedule.Schedule))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+func (this *Command) GetSchedule(token auth.Token, id string) (Schedule, error) {
+	this.scheduler.mux.Lock()
+	defer this.scheduler.mux.Unlock()
+	schedule, ok := this.scheduler.schedules[id]
+	if !ok || schedule.UserId != token.GetUserId() {
+		return Schedule{}, ErrScheduleNotFound
+	}
+	return copySchedule(schedule.Schedule), nil
+}
+
+// DeleteSchedule stops future executions; an execution already in progress is finished but not recorded
+func (this *Command) DeleteSchedule(token auth.Token, id string) error {
+	this.scheduler.mux.Lock()
+	defer this.scheduler.mux.Unlock()
+	schedule, ok := this.scheduler.schedules[id]
+	if !ok || schedule.UserId != token.GetUserId() {
+		return ErrScheduleNotFound
+	}
+	err := this.scheduler.backend.Delete(id)
+	if err != nil {
+		return err
+	}
+	delete(this.scheduler.schedules, id)
+	return nil
+}
+
+// startScheduler checks every second for due schedules; schedules missed while the service was down are executed once on start
+func (this *Command) startScheduler(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				for _, schedule := range this.scheduler.due(now) {
+					go this.runSchedule(ctx, schedule)
+				}
+			}
+		}
+	}()
+}
+
+func (this *scheduler) due(now time.Time) (result []storedSchedule) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for id, schedule := range this.schedules {
+		if schedule.NextRun != nil && !schedule.NextRun.After(now) && !this.running[id] {
+			this.running[id] = true
+			result = append(result, *schedule)
+		}
+	}
+	return result
+}
+
+// runSchedule executes the schedule with the service context; running schedules are canceled on shutdown
+func (this *Command) runSchedule(ctx context.Context, schedule storedSchedule) {
+	start := time.Now()
+	var results []BatchResultElement
+	token, err := this.getScheduleToken(schedule)
+	if err != nil {
+		log.Println("ERROR: unable to get token for schedule", schedule.Id, err)
+		results = []BatchResultElement{{StatusCode: http.StatusUnauthorized, Message: err.Error()}}
+	} else if schedule.Command != nil {
+		code, resp := this.Command(ctx, token, *schedule.Command, schedule.Timeout, schedule.PreferEventValue)
+		results = []BatchResultElement{NewBatchResultElement(code, resp)}
+	} else {
+		results = this.Batch(ctx, token, schedule.Batch, schedule.Timeout, schedule.PreferEventValue)
+	}
+	if this.config.Debug {
+		log.Println("DEBUG: executed schedule", schedule.Id, time.Since(start))
+	}
+	err = this.scheduler.finish(schedule.Id, ScheduleExecution{Time: start, Results: results})
+	if err != nil {
+		log.Println("ERROR: unable to store schedule execution", schedule.Id, err)
+	}
+}
+
+func (this *scheduler) finish(id string, execution ScheduleExecution) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.running, id)
+	schedule, ok := this.schedules[id]
+	if !ok {
+		return nil //deleted while running
+	}
+	schedule.History = append(schedule.History, execution)
+	if len(schedule.History) > this.historySize {
+		schedule.History = schedule.History[len(schedule.History)-this.historySize:]
+	}
+	if schedule.Cron != "" {
+		schedule.NextRun = nextRun(schedule.ScheduleRequest, time.Now())
+	} else {
+		schedule.NextRun = nil
+	}
+	return this.backend.Set(id, *schedule)
+}
+
+func (this *Command) scheduleTokenExchangeEnabled() bool {
+	return this.config.AuthEnabled() && this.config.ScheduleAuthClientSecret != "" && this.config.ScheduleAuthClientSecret != "-"
+}
+
+// getScheduleToken returns the token of the schedule owner.
+// if schedule_auth_client_secret is configured, a fresh token is requested by token exchange,
+// otherwise the in-memory token of the creating request is used (only run_at schedules)
+func (this *Command) getScheduleToken(schedule storedSchedule) (token auth.Token, err error) {
+	if this.scheduleTokenExchangeEnabled() {
+		token, _, err = auth.ExchangeUserToken(this.config.AuthEndpoint, this.config.AuthClientId, this.config.ScheduleAuthClientSecret, schedule.UserId)
+		return token, err
+	}
+	if schedule.token == "" {
+		return token, errScheduleTokenLost
+	}
+	return auth.Parse(schedule.token)
+}
+
+func nextRun(request ScheduleRequest, now time.Time) *time.Time {
+	if request.RunAt != nil {
+		result := *request.RunAt
+		return &result
+	}
+	expr, err := cron.Parse(request.Cron)
+	if err != nil {
+		return nil
+	}
+	result := expr.Next(now.UTC())
+	if result.IsZero() {
+		return nil
+	}
+	return &result
+}
+
+func copySchedule(schedule Schedule) Schedule {
+	schedule.History = append([]ScheduleExecution{}, schedule.History...)
+	return schedule
+}
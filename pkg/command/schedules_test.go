@@ -0,0 +1,47 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCreateScheduleRejectsRunAtAfterTokenExpiration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := newTestCommand(t, ctx, newDeviceMock(), nil, nil)
+	token := testToken(t) //expires in one hour
+
+	request := func(runAt time.Time) ScheduleRequest {
+		return ScheduleRequest{Command: &CommandMessage{FunctionId: testSetFunctionId, DeviceId: "lamp", ServiceId: "service", Input: 1}, RunAt: &runAt}
+	}
+
+	_, err := cmd.CreateSchedule(token, request(time.Now().Add(2*time.Hour)))
+	if !errors.Is(err, ErrScheduleTokenExpiresBeforeRun) {
+		t.Error(err)
+	}
+	_, err = cmd.CreateSchedule(token, request(time.Now().Add(time.Minute)))
+	if err != nil {
+		t.Error(err)
+	}
+	if schedules := cmd.ListSchedules(token); len(schedules) != 1 {
+		t.Error(schedules)
+	}
+}
@@ -0,0 +1,230 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+)
+
+type SequenceRequest struct {
+	Steps    []SequenceStep `json:"steps"`
+	Rollback bool           `json:"rollback,omitempty"` //on failure, compensate the already executed steps in reverse order
+}
+
+type SequenceStep struct {
+	Command CommandMessage `json:"command"`
+	Delay   string         `json:"delay,omitempty"` //optional; wait before the step is executed
+
+	//optional compensation used by rollback; either an explicit command or restore_from
+	Compensation *CommandMessage `json:"compensation,omitempty"`
+	//measuring device command which is executed before the step; on rollback the step command is repeated with the measured value as input,
+	//using the characteristic of restore_from
+	RestoreFrom *CommandMessage `json:"restore_from,omitempty"`
+}
+
+const (
+	SequencePhaseRead         = "read"
+	SequencePhaseStep         = "step"
+	SequencePhaseCompensation = "compensation"
+)
+
+type SequenceTraceElement struct {
+	Step       int         `json:"step"`
+	Phase      string      `json:"phase"`
+	StatusCode int         `json:"status_code"`
+	Message    interface{} `json:"message"`
+	Start      time.Time   `json:"start"`
+	DurationMs int64       `json:"duration_ms"`
+}
+
+type SequenceResult struct {
+	Succeeded  bool                   `json:"succeeded"`
+	FailedStep *int                   `json:"failed_step,omitempty"`
+	RolledBack bool                   `json:"rolled_back"`
+	Trace      []SequenceTraceElement `json:"trace"`
+}
+
+func (this SequenceRequest) Validate() error {
+	if len(this.Steps) == 0 {
+		return errors.New("expect at least one step")
+	}
+	for i, step := range this.Steps {
+		prefix := "[" + strconv.Itoa(i) + "]: "
+		err := step.Command.Validate()
+		if err != nil {
+			return errors.New(prefix + err.Error())
+		}
+		if step.Delay != "" {
+			delay, err := time.ParseDuration(step.Delay)
+			if err != nil {
+				return errors.New(prefix + err.Error())
+			}
+			if delay < 0 {
+				return errors.New(prefix + "delay must not be negative")
+			}
+		}
+		if step.Compensation != nil && step.RestoreFrom != nil {
+			return errors.New(prefix + "expect either compensation or restore_from")
+		}
+		if step.Compensation != nil {
+			err = step.Compensation.Validate()
+			if err != nil {
+				return errors.New(prefix + "compensation: " + err.Error())
+			}
+		}
+		if step.RestoreFrom != nil {
+			err = step.RestoreFrom.Validate()
+			if err != nil {
+				return errors.New(prefix + "restore_from: " + err.Error())
+			}
+			if step.RestoreFrom.DeviceId == "" || !isMeasuringFunctionId(step.RestoreFrom.FunctionId) {
+				return errors.New(prefix + "restore_from expects a measuring device command")
+			}
+			if step.Command.DeviceId == "" {
+				return errors.New(prefix + "restore_from expects a device command as step")
+			}
+		}
+	}
+	return nil
+}
+
+// Sequence executes the steps in order and stops at the first failing step or when ctx is done.
+// if request.Rollback is set, the compensations of the executed steps are run in reverse order after a failure,
+// also if ctx is done.
+func (this *Command) Sequence(ctx context.Context, token auth.Token, request SequenceRequest, timeout string, preferEventValue bool) (result SequenceResult) {
+	result.Trace = []SequenceTraceElement{}
+	compensations := make([]*CommandMessage, len(request.Steps))
+	for i, step := range request.Steps {
+		if step.Delay != "" {
+			delay, _ := time.ParseDuration(step.Delay)
+			start := time.Now()
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				code, commandErr := loadError(ctx, ErrCodeCanceled, "sequence stopped during delay", AffectedIds{})
+				result.Trace = append(result.Trace, SequenceTraceElement{Step: i, Phase: SequencePhaseStep, StatusCode: code, Message: commandErr, Start: start, DurationMs: time.Since(start).Milliseconds()})
+				this.failSequence(ctx, token, &result, i, request.Rollback, compensations, timeout, preferEventValue)
+				return result
+			case <-timer.C:
+			}
+		}
+		if step.RestoreFrom != nil {
+			element, value := this.sequenceTraceStep(ctx, token, i, SequencePhaseRead, *step.RestoreFrom, timeout, preferEventValue)
+			result.Trace = append(result.Trace, element)
+			if element.StatusCode != http.StatusOK {
+				this.failSequence(ctx, token, &result, i, request.Rollback, compensations, timeout, preferEventValue)
+				return result
+			}
+			compensation, err := this.restoreCommand(ctx, step, firstDeviceResult(value))
+			if err != nil {
+				code, commandErr := loadError(ctx, ErrCodeFunctionLoad, "unable to resolve characteristic of restore_from: "+err.Error(), AffectedIds{DeviceId: step.RestoreFrom.DeviceId, ServiceId: step.RestoreFrom.ServiceId, FunctionId: step.RestoreFrom.FunctionId})
+				result.Trace = append(result.Trace, SequenceTraceElement{Step: i, Phase: SequencePhaseRead, StatusCode: code, Message: commandErr, Start: element.Start})
+				this.failSequence(ctx, token, &result, i, request.Rollback, compensations, timeout, preferEventValue)
+				return result
+			}
+			compensations[i] = &compensation
+		} else {
+			compensations[i] = step.Compensation
+		}
+		element, _ := this.sequenceTraceStep(ctx, token, i, SequencePhaseStep, step.Command, timeout, preferEventValue)
+		result.Trace = append(result.Trace, element)
+		if element.StatusCode != http.StatusOK {
+			//the failed step itself is not compensated
+			compensations[i] = nil
+			this.failSequence(ctx, token, &result, i, request.Rollback, compensations, timeout, preferEventValue)
+			return result
+		}
+	}
+	result.Succeeded = true
+	return result
+}
+
+// restoreCommand repeats the step command with the value read by restore_from.
+// the value is given in the characteristic of restore_from, which is resolved from its function if not set explicitly.
+func (this *Command) restoreCommand(ctx context.Context, step SequenceStep, value interface{}) (result CommandMessage, err error) {
+	result = step.Command
+	result.Input = value
+	result.Condition = nil
+	result.CallbackUrl = ""
+	result.CharacteristicId = step.RestoreFrom.CharacteristicId
+	if result.CharacteristicId == "" {
+		function, err := this.iot.GetFunction(ctx, step.RestoreFrom.FunctionId)
+		if err != nil {
+			return result, err
+		}
+		if function.ConceptId != "" {
+			concept, err := this.iot.GetConcept(ctx, function.ConceptId)
+			if err != nil {
+				return result, err
+			}
+			result.CharacteristicId = concept.BaseCharacteristicId
+		}
+	}
+	return result, nil
+}
+
+func (this *Command) failSequence(ctx context.Context, token auth.Token, result *SequenceResult, failedStep int, rollback bool, compensations []*CommandMessage, timeout string, preferEventValue bool) {
+	result.FailedStep = &failedStep
+	if !rollback {
+		return
+	}
+	result.RolledBack = true
+	for i := failedStep; i >= 0; i-- {
+		if compensations[i] == nil {
+			continue
+		}
+		compensationCtx, cancel := this.compensationContext(ctx, timeout)
+		element, _ := this.sequenceTraceStep(compensationCtx, token, i, SequencePhaseCompensation, *compensations[i], timeout, preferEventValue)
+		cancel()
+		result.Trace = append(result.Trace, element)
+	}
+}
+
+// compensationContext lets a compensation finish after ctx is canceled (e.g. by a disconnected client);
+// each compensation gets its own timeout instead of the possibly expired deadline of ctx
+func (this *Command) compensationContext(ctx context.Context, timeout string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), this.getTimeoutDuration(timeout))
+	return context.WithValue(ctx, timeoutCtxKey{}, true), cancel
+}
+
+func (this *Command) sequenceTraceStep(ctx context.Context, token auth.Token, index int, phase string, cmd CommandMessage, timeout string, preferEventValue bool) (element SequenceTraceElement, resp interface{}) {
+	start := time.Now()
+	code, resp := this.Command(ctx, token, cmd, timeout, preferEventValue)
+	return SequenceTraceElement{
+		Step:       index,
+		Phase:      phase,
+		StatusCode: code,
+		Message:    resp,
+		Start:      start,
+		DurationMs: time.Since(start).Milliseconds(),
+	}, resp
+}
+
+// device commands respond with a list containing one value
+func firstDeviceResult(resp interface{}) interface{} {
+	if list, ok := resp.([]interface{}); ok && len(list) == 1 {
+		return list[0]
+	}
+	return resp
+}
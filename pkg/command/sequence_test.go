@@ -0,0 +1,104 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSequenceCompensatesAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := newDeviceMock()
+	devices.delay = 50 * time.Millisecond
+	devices.set("a", 0.0)
+	cmd := newTestCommand(t, ctx, devices, nil, nil)
+	token := testToken(t)
+
+	t.Run("cancel during delay", func(t *testing.T) {
+		requestCtx, cancelRequest := context.WithCancel(ctx)
+		time.AfterFunc(500*time.Millisecond, cancelRequest)
+		result := cmd.Sequence(requestCtx, token, SequenceRequest{
+			Rollback: true,
+			Steps: []SequenceStep{
+				{
+					Command:      CommandMessage{FunctionId: testSetFunctionId, DeviceId: "a", ServiceId: "service", Input: 1},
+					Compensation: &CommandMessage{FunctionId: testSetFunctionId, DeviceId: "a", ServiceId: "service", Input: 0},
+				},
+				{
+					Delay:   "10s",
+					Command: CommandMessage{FunctionId: testSetFunctionId, DeviceId: "b", ServiceId: "service", Input: 1},
+				},
+			},
+		}, "5s", false)
+		if result.Succeeded || !result.RolledBack || result.FailedStep == nil || *result.FailedStep != 1 {
+			t.Errorf("%#v", result)
+			return
+		}
+		last := result.Trace[len(result.Trace)-1]
+		if last.Phase != SequencePhaseCompensation || last.StatusCode != http.StatusOK {
+			t.Errorf("%#v", last)
+		}
+		if value := devices.get("a"); value != 0.0 {
+			t.Error("expected compensated value", value)
+		}
+		if devices.sentTo("b", testSetFunctionId) != 0 {
+			t.Error("step after cancel was sent")
+		}
+	})
+
+	t.Run("cancel while waiting for response", func(t *testing.T) {
+		devices.set("a", 0.0)
+		requestCtx, cancelRequest := context.WithCancel(ctx)
+		time.AfterFunc(75*time.Millisecond, cancelRequest)
+		result := cmd.Sequence(requestCtx, token, SequenceRequest{
+			Rollback: true,
+			Steps: []SequenceStep{
+				{
+					Command:      CommandMessage{FunctionId: testSetFunctionId, DeviceId: "a", ServiceId: "service", Input: 2},
+					Compensation: &CommandMessage{FunctionId: testSetFunctionId, DeviceId: "a", ServiceId: "service", Input: 0},
+				},
+				{
+					Command:      CommandMessage{FunctionId: testSetFunctionId, DeviceId: "c", ServiceId: "service", Input: 2},
+					Compensation: &CommandMessage{FunctionId: testSetFunctionId, DeviceId: "c", ServiceId: "service", Input: 0},
+				},
+			},
+		}, "5s", false)
+		if result.Succeeded || !result.RolledBack || result.FailedStep == nil || *result.FailedStep != 1 {
+			t.Errorf("%#v", result)
+			return
+		}
+		compensations := 0
+		for _, element := range result.Trace {
+			if element.Phase == SequencePhaseCompensation {
+				compensations++
+				if element.StatusCode != http.StatusOK || element.Step != 0 {
+					t.Errorf("%#v", element)
+				}
+			}
+		}
+		if compensations != 1 {
+			t.Error("expected compensation of step 0 only", result.Trace)
+		}
+		if value := devices.get("a"); value != 0.0 {
+			t.Error("expected compensated value", value)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StageLoadMetadata    = "load_metadata"
+	StageLastEventValue  = "last_event_value"
+	StageMarshal         = "marshal"
+	StageProduce         = "produce"
+	StageWaitForResponse = "wait_for_response"
+)
+
+// StageTimings collects how much time the stages of a command used
+type StageTimings struct {
+	mux    sync.Mutex
+	stages []StageTiming
+}
+
+type StageTiming struct {
+	Stage    string        `json:"stage"`
+	Duration time.Duration `json:"duration"`
+}
+
+type stageTimingsKey struct{}
+
+// WithStageTimings returns a context in which commands record their stage timings to the returned StageTimings
+func WithStageTimings(ctx context.Context) (context.Context, *StageTimings) {
+	timings := &StageTimings{}
+	return context.WithValue(ctx, stageTimingsKey{}, timings), timings
+}
+
+// recordStage adds the time since start to the StageTimings of ctx, if ctx has one
+func recordStage(ctx context.Context, stage string, start time.Time) {
+	timings, ok := ctx.Value(stageTimingsKey{}).(*StageTimings)
+	if !ok {
+		return
+	}
+	duration := time.Since(start)
+	timings.mux.Lock()
+	defer timings.mux.Unlock()
+	for i, element := range timings.stages {
+		if element.Stage == stage {
+			timings.stages[i].Duration += duration
+			return
+		}
+	}
+	timings.stages = append(timings.stages, StageTiming{Stage: stage, Duration: duration})
+}
+
+// List returns the summed duration per stage in the order the stages were first recorded
+func (this *StageTimings) List() []StageTiming {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return append([]StageTiming{}, this.stages...)
+}
+
+// ServerTiming formats the timings as value of a Server-Timing http header
+func (this *StageTimings) ServerTiming() string {
+	parts := []string{}
+	for _, element := range this.List() {
+		parts = append(parts, element.Stage+";dur="+strconv.FormatFloat(float64(element.Duration.Microseconds())/1000, 'f', -1, 64))
+	}
+	return strings.Join(parts, ", ")
+}
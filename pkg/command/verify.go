@@ -0,0 +1,181 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+)
+
+const maxVerificationRetries = 10
+
+const (
+	VerificationVerified = "verified"
+	VerificationMismatch = "mismatch"
+	VerificationError    = "error"
+)
+
+// Verification re-reads the value of a controlling device command through the matching measuring function
+type Verification struct {
+	Delay     string  `json:"delay,omitempty"`     //wait between command and read back; defaults to verify_default_delay
+	Tolerance float64 `json:"tolerance,omitempty"` //allowed absolute difference of numeric values
+	Retries   int     `json:"retries,omitempty"`   //number of times the command is resent on mismatch
+}
+
+// VerificationResult is the response of a command with verify
+type VerificationResult struct {
+	Outcome  string      `json:"outcome"` //"verified" || "mismatch" || "error"
+	Observed interface{} `json:"observed"`
+	Attempts int         `json:"attempts"`
+	Error    string      `json:"error,omitempty"`
+	Result   interface{} `json:"result"`
+}
+
+func (this Verification) Validate() error {
+	if this.Delay != "" {
+		delay, err := time.ParseDuration(this.Delay)
+		if err != nil {
+			return err
+		}
+		if delay < 0 {
+			return errors.New("verify delay must not be negative")
+		}
+	}
+	if this.Tolerance < 0 {
+		return errors.New("verify tolerance must not be negative")
+	}
+	if this.Retries < 0 || this.Retries > maxVerificationRetries {
+		return errors.New("verify retries must be between 0 and 10")
+	}
+	return nil
+}
+
+// verifiedCommand sends the command and compares the read back value with the input; on mismatch the command is resent up to Verify.Retries times
+// a failed read back stops the verification with the outcome "error" because resending would not make the value readable
+func (this *Command) verifiedCommand(ctx context.Context, token auth.Token, cmd CommandMessage, timeout string, preferEventValue bool, asyncTaskId string) (code int, resp interface{}) {
+	verification := *cmd.Verify
+	cmd.Verify = nil
+	delay := this.getVerifyDelay(verification)
+	result := VerificationResult{}
+	ids := AffectedIds{DeviceId: cmd.DeviceId, ServiceId: cmd.ServiceId, FunctionId: cmd.FunctionId}
+	for attempt := 0; attempt <= verification.Retries; attempt++ {
+		result.Attempts = attempt + 1
+		code, result.Result = this.command(ctx, token, cmd, timeout, preferEventValue, asyncTaskId)
+		if code != http.StatusOK {
+			return code, result.Result
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return loadError(ctx, ErrCodeCanceled, "verify stopped during delay", ids)
+		case <-timer.C:
+		}
+		observed, err := this.readCurrentValue(ctx, token, cmd, timeout, false)
+		if errors.Is(err, errNotControllingFunction) {
+			return http.StatusBadRequest, NewCommandError(http.StatusBadRequest, ErrCodeInvalidRequest, "verify: "+err.Error(), ids)
+		}
+		if err != nil && ctx.Err() != nil {
+			return loadError(ctx, ErrCodeCanceled, "verify read back: "+err.Error(), ids)
+		}
+		if err != nil {
+			result.Outcome = VerificationError
+			result.Error = err.Error()
+			return code, result
+		}
+		result.Observed = firstDeviceResult(observed)
+		result.Error = ""
+		if valuesMatch(cmd.Input, result.Observed, verification.Tolerance) {
+			result.Outcome = VerificationVerified
+			return code, result
+		}
+		result.Outcome = VerificationMismatch
+	}
+	return code, result
+}
+
+func (this *Command) getVerifyDelay(verification Verification) time.Duration {
+	if verification.Delay != "" {
+		delay, err := time.ParseDuration(verification.Delay)
+		if err == nil {
+			return delay
+		}
+	}
+	if this.config.VerifyDefaultDelay != "" && this.config.VerifyDefaultDelay != "-" {
+		delay, err := time.ParseDuration(this.config.VerifyDefaultDelay)
+		if err == nil {
+			return delay
+		}
+	}
+	return time.Second
+}
+
+// valuesMatch compares the json representations of expected and actual; numbers may differ by tolerance
+func valuesMatch(expected interface{}, actual interface{}, tolerance float64) bool {
+	return normalizedValuesMatch(normalizeJsonValue(expected), normalizeJsonValue(actual), tolerance)
+}
+
+func normalizedValuesMatch(expected interface{}, actual interface{}, tolerance float64) bool {
+	switch e := expected.(type) {
+	case float64:
+		a, ok := actual.(float64)
+		return ok && math.Abs(e-a) <= tolerance
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for i := range e {
+			if !normalizedValuesMatch(e[i], a[i], tolerance) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for key, value := range e {
+			if !normalizedValuesMatch(value, a[key], tolerance) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(expected, actual)
+	}
+}
+
+func normalizeJsonValue(value interface{}) (result interface{}) {
+	temp, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	err = json.Unmarshal(temp, &result)
+	if err != nil {
+		return value
+	}
+	return result
+}
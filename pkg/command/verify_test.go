@@ -0,0 +1,76 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestVerifiedCommand(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices := newDeviceMock()
+	devices.set("lamp", 0.0)
+	devices.set("stuck", 0.0)
+	devices.ignoringSet["stuck"] = true
+	cmd := newTestCommand(t, ctx, devices, nil, nil)
+	token := testToken(t)
+
+	t.Run("verified", func(t *testing.T) {
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, DeviceId: "lamp", ServiceId: "service", Input: 42, Verify: &Verification{Delay: "10ms", Retries: 2}}, "5s", false)
+		result, ok := resp.(VerificationResult)
+		if code != http.StatusOK || !ok {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if result.Outcome != VerificationVerified || result.Attempts != 1 || result.Observed != 42.0 {
+			t.Errorf("%#v", result)
+		}
+	})
+
+	t.Run("tolerance", func(t *testing.T) {
+		devices.set("stuck", 41.5)
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, DeviceId: "stuck", ServiceId: "service", Input: 42, Verify: &Verification{Delay: "10ms", Tolerance: 1}}, "5s", false)
+		result, ok := resp.(VerificationResult)
+		if code != http.StatusOK || !ok {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if result.Outcome != VerificationVerified || result.Observed != 41.5 {
+			t.Errorf("%#v", result)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		devices.set("stuck", 0.0)
+		before := devices.sentTo("stuck", testSetFunctionId)
+		code, resp := cmd.Command(ctx, token, CommandMessage{FunctionId: testSetFunctionId, DeviceId: "stuck", ServiceId: "service", Input: 42, Verify: &Verification{Delay: "10ms", Retries: 2}}, "5s", false)
+		result, ok := resp.(VerificationResult)
+		if code != http.StatusOK || !ok {
+			t.Errorf("%v %#v", code, resp)
+			return
+		}
+		if result.Outcome != VerificationMismatch || result.Attempts != 3 || result.Observed != 0.0 {
+			t.Errorf("%#v", result)
+		}
+		if count := devices.sentTo("stuck", testSetFunctionId) - before; count != 3 {
+			t.Error(count)
+		}
+	})
+}
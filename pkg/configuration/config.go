@@ -53,9 +53,61 @@ type Config struct {
 	DefaultTimeout         string        `json:"default_timeout"`
 	DefaultTimeoutDuration time.Duration `json:"-"`
 
+	AsyncTaskRetention string `json:"async_task_retention"` //how long results of async commands are kept after completion
+
+	IdempotencyWindow string `json:"idempotency_window"` //how long results are kept for repeated Idempotency-Key headers; "" or "-" disables the header
+
+	ResultCacheMaxAge string `json:"result_cache_max_age"` //upper limit for the max_age of measuring commands; "" or "-" disables the result cache
+
+	RateLimitUser               float64 `json:"rate_limit_user"`           //commands per second and user; 0 disables the limit
+	RateLimitUserBurst          int64   `json:"rate_limit_user_burst"`     //commands a user may send at once
+	RateLimitDevice             float64 `json:"rate_limit_device"`         //commands per second and device; 0 disables the limit
+	RateLimitDeviceBurst        int64   `json:"rate_limit_device_burst"`   //commands a device may receive at once
+	RateLimitDeviceService      float64 `json:"rate_limit_device_service"` //commands per second and service of a device; 0 disables the limit
+	RateLimitDeviceServiceBurst int64   `json:"rate_limit_device_service_burst"`
+
+	AuditSink        string `json:"audit_sink"`          //"file" || "kafka"; "" or "-" disables audit records; GET /audit needs "file"
+	AuditFile        string `json:"audit_file"`          //used by audit_sink "file"
+	AuditFileMaxSize int64  `json:"audit_file_max_size"` //bytes; audit_file is rotated to audit_file+".1" when exceeded; 0 disables the rotation
+	AuditKafkaTopic  string `json:"audit_kafka_topic"`   //used by audit_sink "kafka"
+
+	DeviceHistorySize int64  `json:"device_history_size"` //number of recent commands kept per device; 0 disables the device history
+	DeviceHistoryDir  string `json:"device_history_dir"`  //device histories are stored in this dir; "" or "-" keeps them in memory
+
+	HealthCheckTimeout string `json:"health_check_timeout"` //upper limit for the dependency checks of /health/ready
+
+	MissingLastValueNotFound bool `json:"missing_last_value_not_found"` //responds to missing last event values with 404 instead of the legacy custom status 513; the error_code "missing_last_value" is the same for both
+
+	MetricsHighCardinalityLabels bool `json:"metrics_high_cardinality_labels"` //fills the user_id and device_id labels of metrics, which creates series per user and device; off by default, enable only for small deployments or debugging
+
+	TracingExporter     string `json:"tracing_exporter"`      //"otlp" || "stdout" || "file"; "" or "-" disables the export of spans
+	TracingOtlpEndpoint string `json:"tracing_otlp_endpoint"` //used by tracing_exporter "otlp"; otlp/http url
+	TracingFile         string `json:"tracing_file"`          //used by tracing_exporter "file"; spans are appended as json
+	TracingServiceName  string `json:"tracing_service_name"`
+
+	RegisterBackend    string `json:"register_backend"`     //"memory" || "bolt" || "file" defaults to "memory"; "bolt" (embedded database) and "file" (json file per entry) keep pending commands and async tasks over restarts
+	RegisterBackendDir string `json:"register_backend_dir"` //used by register_backend "bolt" and "file"
+
+	VerifyDefaultDelay string `json:"verify_default_delay"` //wait between a verified command and its read back, if the command does not define a delay
+
+	ScheduleStorageDir       string `json:"schedule_storage_dir"`                        //schedules are stored in this dir; "" or "-" keeps them in memory
+	ScheduleHistorySize      int64  `json:"schedule_history_size"`                       //number of recent executions kept per schedule
+	ScheduleAuthClientSecret string `json:"schedule_auth_client_secret" config:"secret"` //token exchange (auth_endpoint, auth_client_id) for schedule executions; required for cron schedules, run_at schedules without it use the in-memory token of their creation and must run before it expires
+
+	CallbackSecret         string   `json:"callback_secret" config:"secret"` //used to sign callback requests with HMAC-SHA256
+	CallbackMaxRetries     int64    `json:"callback_max_retries"`
+	CallbackInitialBackoff string   `json:"callback_initial_backoff"`
+	CallbackMaxBackoff     string   `json:"callback_max_backoff"`   //upper limit for the exponential retry backoff
+	CallbackAllowedHosts   []string `json:"callback_allowed_hosts"` //if set, callbacks are only sent to these hosts (".example.com" matches subdomains); else all hosts with public addresses are allowed
+
 	KafkaConsumerGroup string `json:"kafka_consumer_group"`
 	ResponseTopic      string `json:"response_topic"`
-	GroupScheduler     string `json:"group_scheduler"`
+	GroupScheduler     string `json:"group_scheduler"` //"parallel" || "sequential" || "rolling"; default rollout of group sub commands and batch commands
+
+	GroupMaxConcurrency     int64  `json:"group_max_concurrency"`      //parallel rollout: max number of running commands; 0 is unlimited
+	GroupRollingWaveSize    int64  `json:"group_rolling_wave_size"`    //rolling rollout: number of commands started together
+	GroupRollingWaveDelay   string `json:"group_rolling_wave_delay"`   //rolling rollout: pause after each wave
+	GroupAbortAfterFailures int64  `json:"group_abort_after_failures"` //commands not started yet are skipped after this many failures; 0 never aborts
 
 	MetadataResponseTo string `json:"metadata_response_to"`
 
@@ -77,6 +129,11 @@ type Config struct {
 
 	TopicSuffixForScaling string `json:"topic_suffix_for_scaling"` //only for kafka & cloud
 
+	ScalingMode           string `json:"scaling_mode"`                           //"suffix" || "shared" defaults to "suffix"; "shared" lets all replicas use the same response topic (only for kafka & cloud)
+	ReplicaAddress        string `json:"replica_address"`                        //host:port other replicas use to forward responses to this replica; defaults to first non-loopback ip and server_port
+	ReplicaForwardSecret  string `json:"replica_forward_secret" config:"secret"` //shared between replicas to authenticate forwarded responses; required for scaling_mode "shared"
+	ReplicaAddressPattern string `json:"replica_address_pattern"`                //regular expression for the host:port of replicas responses may be forwarded to, e.g. "10\\.0\\.[0-9]+\\.[0-9]+:8080"; required for scaling_mode "shared"
+
 	KafkaTopicConfigs map[string][]kafka.ConfigEntry `json:"kafka_topic_configs"`
 
 	MgwCorrelationIdPrefix string `json:"mgw_correlation_id_prefix"`
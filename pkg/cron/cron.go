@@ -0,0 +1,158 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed standard cron expression: "minute hour day-of-month month day-of-week"
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type bounds struct {
+	min, max int
+}
+
+var fieldBounds = []bounds{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+var fieldNames = []string{"minute", "hour", "day-of-month", "month", "day-of-week"}
+
+func Parse(expr string) (result Schedule, err error) {
+	expr = strings.TrimSpace(expr)
+	if replacement, ok := descriptors[expr]; ok {
+		expr = replacement
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return result, errors.New("invalid cron expression: expect 5 fields")
+	}
+	values := make([]uint64, 5)
+	for i, field := range fields {
+		values[i], err = parseField(field, fieldBounds[i])
+		if err != nil {
+			return result, fmt.Errorf("invalid cron expression: %v field: %w", fieldNames[i], err)
+		}
+	}
+	//sunday may be 0 or 7
+	if values[4]&(1<<7) != 0 {
+		values[4] = values[4] | 1
+	}
+	return Schedule{
+		minute:        values[0],
+		hour:          values[1],
+		dom:           values[2],
+		month:         values[3],
+		dow:           values[4],
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}, nil
+}
+
+func parseField(field string, b bounds) (result uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if index := strings.Index(part, "/"); index >= 0 {
+			step, err = strconv.Atoi(part[index+1:])
+			if err != nil || step <= 0 {
+				return 0, errors.New("invalid step in " + part)
+			}
+			part = part[:index]
+		}
+		start, end := b.min, b.max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			rangeParts := strings.SplitN(part, "-", 2)
+			start, err = strconv.Atoi(rangeParts[0])
+			if err != nil {
+				return 0, errors.New("invalid range " + part)
+			}
+			end, err = strconv.Atoi(rangeParts[1])
+			if err != nil {
+				return 0, errors.New("invalid range " + part)
+			}
+		default:
+			start, err = strconv.Atoi(part)
+			if err != nil {
+				return 0, errors.New("invalid value " + part)
+			}
+			end = start
+			if step > 1 {
+				end = b.max
+			}
+		}
+		if start < b.min || end > b.max || start > end {
+			return 0, errors.New("value out of range in " + part)
+		}
+		for i := start; i <= end; i += step {
+			result = result | 1<<uint(i)
+		}
+	}
+	return result, nil
+}
+
+// Next returns the first matching time after t (with minute precision)
+func (this Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if this.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !this.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if this.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if this.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// standard cron semantic: if day-of-month and day-of-week are both restricted, either may match
+func (this Schedule) dayMatches(t time.Time) bool {
+	domMatch := this.dom&(1<<uint(t.Day())) != 0
+	dowMatch := this.dow&(1<<uint(t.Weekday())) != 0
+	if this.domRestricted && this.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
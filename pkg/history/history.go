@@ -0,0 +1,120 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/SENERGY-Platform/device-command/pkg/storage"
+)
+
+// Stored is the persisted history of a key
+type Stored[T any] struct {
+	Key     string `json:"key"`
+	Entries []T    `json:"entries"` //most recent last
+}
+
+// Store keeps the last size entries per key.
+// Add only updates memory; changed histories are written to the backend in the background and once more when ctx is done.
+type Store[T any] struct {
+	histories  map[string][]T
+	dirty      map[string]bool
+	mux        sync.Mutex
+	persistMux sync.Mutex
+	changed    chan struct{}
+	backend    storage.Backend[Stored[T]]
+	size       int
+}
+
+// New loads the stored histories from backend
+func New[T any](ctx context.Context, size int, backend storage.Backend[Stored[T]]) (*Store[T], error) {
+	result := &Store[T]{
+		histories: map[string][]T{},
+		dirty:     map[string]bool{},
+		changed:   make(chan struct{}, 1),
+		backend:   backend,
+		size:      size,
+	}
+	stored, err := backend.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, history := range stored {
+		result.histories[history.Key] = history.Entries
+	}
+	go result.persistLoop(ctx)
+	return result, nil
+}
+
+// Add appends entry to the history of key and drops the oldest entries exceeding the size
+func (this *Store[T]) Add(key string, entry T) {
+	this.mux.Lock()
+	entries := append(this.histories[key], entry)
+	if len(entries) > this.size {
+		entries = slices.Clone(entries[len(entries)-this.size:])
+	}
+	this.histories[key] = entries
+	this.dirty[key] = true
+	this.mux.Unlock()
+	select {
+	case this.changed <- struct{}{}:
+	default:
+	}
+}
+
+// List returns a copy of the history of key, most recent last
+func (this *Store[T]) List(key string) []T {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return append([]T{}, this.histories[key]...)
+}
+
+// Flush writes all changed histories to the backend
+func (this *Store[T]) Flush() (err error) {
+	this.persistMux.Lock()
+	defer this.persistMux.Unlock()
+	this.mux.Lock()
+	pending := make([]Stored[T], 0, len(this.dirty))
+	for key := range this.dirty {
+		pending = append(pending, Stored[T]{Key: key, Entries: slices.Clone(this.histories[key])})
+	}
+	clear(this.dirty)
+	this.mux.Unlock()
+	for _, history := range pending {
+		setErr := this.backend.Set(history.Key, history)
+		if setErr != nil {
+			log.Println("ERROR: unable to store history", history.Key, setErr)
+			err = setErr
+		}
+	}
+	return err
+}
+
+func (this *Store[T]) persistLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			_ = this.Flush()
+			return
+		case <-this.changed:
+			_ = this.Flush()
+		}
+	}
+}
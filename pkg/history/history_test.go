@@ -0,0 +1,131 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/storage"
+)
+
+func TestSizeBound(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := New[string](ctx, 3, storage.NewMemory[Stored[string]]())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 10; i++ {
+		store.Add("d1", strconv.Itoa(i))
+	}
+	store.Add("d2", "a")
+
+	list := store.List("d1")
+	if len(list) != 3 || list[0] != "7" || list[2] != "9" {
+		t.Error(list)
+		return
+	}
+	list[0] = "changed"
+	if store.List("d1")[0] != "7" {
+		t.Error("list must return a copy")
+		return
+	}
+	if list := store.List("d2"); len(list) != 1 || list[0] != "a" {
+		t.Error(list)
+		return
+	}
+	if list := store.List("unknown"); list == nil || len(list) != 0 {
+		t.Error(list)
+		return
+	}
+}
+
+func TestPersistenceReload(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	backend, err := storage.NewFile[Stored[string]](dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	store, err := New[string](ctx, 2, backend)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	store.Add("d1", "a")
+	store.Add("d1", "b")
+	store.Add("d1", "c")
+	store.Add("d2", "x")
+	err = store.Flush()
+	cancel()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	backend2, err := storage.NewFile[Stored[string]](dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	reloaded, err := New[string](ctx2, 2, backend2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if list := reloaded.List("d1"); len(list) != 2 || list[0] != "b" || list[1] != "c" {
+		t.Error(list)
+		return
+	}
+	if list := reloaded.List("d2"); len(list) != 1 || list[0] != "x" {
+		t.Error(list)
+		return
+	}
+}
+
+func TestBackgroundPersistence(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	backend := storage.NewMemory[Stored[string]]()
+	store, err := New[string](ctx, 5, backend)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	store.Add("d1", "a")
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		stored, found, err := backend.Get("d1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if found && len(stored.Entries) == 1 && stored.Entries[0] == "a" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("history was not persisted in the background")
+}
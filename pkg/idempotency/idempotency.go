@@ -0,0 +1,162 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/status"
+)
+
+var ErrKeyReused = errors.New("idempotency key was already used for a different request")
+
+// ErrOutcomeUnknown is returned for keys whose first request was canceled or timed out; its command may have been sent, so it is not executed again
+var ErrOutcomeUnknown = errors.New("outcome of the request with this idempotency key is unknown")
+
+// notKept lists status codes of results which are not stored, so that a retry with the same key is executed again
+var notKept = map[int]bool{
+	http.StatusTooManyRequests: true,
+}
+
+// unknownOutcome lists status codes of results whose command may or may not have been sent
+var unknownOutcome = map[int]bool{
+	status.ClientClosedRequest: true,
+	http.StatusRequestTimeout:  true,
+}
+
+// Store remembers results of requests by user and Idempotency-Key for the duration of window.
+// Requests with a known key receive the stored result or wait for the still running request instead of being executed again.
+type Store struct {
+	entries map[string]*entry
+	mux     sync.Mutex
+	window  time.Duration
+}
+
+type entry struct {
+	fingerprint string
+	done        chan struct{}
+	code        int
+	result      interface{}
+	kept        bool
+	unknown     bool
+	completedAt time.Time
+}
+
+func New(ctx context.Context, window time.Duration) *Store {
+	result := &Store{entries: map[string]*entry{}, window: window}
+	go result.cleanupLoop(ctx)
+	return result
+}
+
+// Fingerprint identifies the request body; a key may only be repeated with the same body
+func Fingerprint(request interface{}) string {
+	temp, _ := json.Marshal(request)
+	hash := sha256.Sum256(temp)
+	return hex.EncodeToString(hash[:])
+}
+
+// Do calls f once per userId and key within the window.
+// repeated calls with the same key return the stored result (replayed = true) or wait until the first call is finished.
+// results with a status code listed in notKept are not stored; callers waiting for such a result call f themselves instead of receiving it.
+// results with a status code listed in unknownOutcome or for which known returns false (known may be nil) are answered with ErrOutcomeUnknown,
+// because executing f again could repeat an already sent command.
+// returns ErrKeyReused if the key is repeated with a different fingerprint and ctx.Err() if ctx is done while waiting.
+func (this *Store) Do(ctx context.Context, userId string, key string, fingerprint string, f func() (code int, result interface{}), known func(code int, result interface{}) bool) (code int, result interface{}, replayed bool, err error) {
+	id := userId + "/" + key
+	var e *entry
+	for {
+		this.mux.Lock()
+		var ok bool
+		e, ok = this.entries[id]
+		if ok && !e.completedAt.IsZero() && time.Since(e.completedAt) > this.window {
+			ok = false
+		}
+		if !ok {
+			e = &entry{fingerprint: fingerprint, done: make(chan struct{})}
+			this.entries[id] = e
+		}
+		this.mux.Unlock()
+
+		if !ok {
+			break
+		}
+		if e.fingerprint != fingerprint {
+			return 0, nil, false, ErrKeyReused
+		}
+		select {
+		case <-e.done:
+			if e.unknown {
+				return 0, nil, false, ErrOutcomeUnknown
+			}
+			if e.kept {
+				return e.code, e.result, true, nil
+			}
+		case <-ctx.Done():
+			return 0, nil, false, ctx.Err()
+		}
+	}
+
+	code, result = f()
+	unknown := unknownOutcome[code] || (known != nil && !known(code, result))
+	kept := !unknown && !notKept[code]
+	this.mux.Lock()
+	e.code = code
+	e.result = result
+	e.kept = kept
+	e.unknown = unknown
+	e.completedAt = time.Now()
+	if !kept && !unknown && this.entries[id] == e {
+		delete(this.entries, id)
+	}
+	this.mux.Unlock()
+	close(e.done)
+	return code, result, false, nil
+}
+
+func (this *Store) cleanupLoop(ctx context.Context) {
+	interval := this.window / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			this.cleanup()
+		}
+	}
+}
+
+func (this *Store) cleanup() {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for id, e := range this.entries {
+		if !e.completedAt.IsZero() && time.Since(e.completedAt) > this.window {
+			delete(this.entries, id)
+		}
+	}
+}
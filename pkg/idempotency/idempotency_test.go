@@ -0,0 +1,194 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/status"
+)
+
+func TestStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(ctx, time.Second)
+
+	calls := atomic.Int64{}
+	f := func() (int, interface{}) {
+		calls.Add(1)
+		time.Sleep(200 * time.Millisecond)
+		return 200, "foo"
+	}
+
+	replayedCount := atomic.Int64{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, result, replayed, err := store.Do(ctx, "user1", "key1", "a", f, nil)
+			if err != nil || code != 200 || result != "foo" {
+				t.Error(code, result, err)
+			}
+			if replayed {
+				replayedCount.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 || replayedCount.Load() != 4 {
+		t.Error(calls.Load(), replayedCount.Load())
+		return
+	}
+
+	_, _, _, err := store.Do(ctx, "user1", "key1", "b", f, nil)
+	if !errors.Is(err, ErrKeyReused) {
+		t.Error(err)
+		return
+	}
+
+	_, _, replayed, err := store.Do(ctx, "user2", "key1", "b", f, nil)
+	if err != nil || replayed || calls.Load() != 2 {
+		t.Error(replayed, err, calls.Load())
+		return
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	_, _, replayed, err = store.Do(ctx, "user1", "key1", "b", f, nil)
+	if err != nil || replayed || calls.Load() != 3 {
+		t.Error("expected new execution after window", replayed, err, calls.Load())
+		return
+	}
+}
+
+func TestCanceledResultHasUnknownOutcome(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(ctx, time.Minute)
+
+	for _, canceledCode := range []int{status.ClientClosedRequest, http.StatusRequestTimeout} {
+		key := strconv.Itoa(canceledCode)
+		code, _, _, _ := store.Do(ctx, "user1", key, "a", func() (int, interface{}) {
+			return canceledCode, "canceled"
+		}, nil)
+		if code != canceledCode {
+			t.Error(code)
+			return
+		}
+		_, _, _, err := store.Do(ctx, "user1", key, "a", func() (int, interface{}) {
+			t.Error("command executed again")
+			return 200, "foo"
+		}, nil)
+		if !errors.Is(err, ErrOutcomeUnknown) {
+			t.Error(err)
+			return
+		}
+	}
+}
+
+func TestRateLimitedResultIsNotKept(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(ctx, time.Minute)
+
+	store.Do(ctx, "user1", "key1", "a", func() (int, interface{}) {
+		return http.StatusTooManyRequests, "rate limited"
+	}, nil)
+	code, result, replayed, err := store.Do(ctx, "user1", "key1", "a", func() (int, interface{}) {
+		return 200, "foo"
+	}, nil)
+	if err != nil || replayed || code != 200 || result != "foo" {
+		t.Error(code, result, replayed, err)
+		return
+	}
+}
+
+func TestRejectedResultHasUnknownOutcome(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(ctx, time.Minute)
+
+	known := func(code int, result interface{}) bool {
+		return result != "partially canceled"
+	}
+	store.Do(ctx, "user1", "key1", "a", func() (int, interface{}) {
+		return 200, "partially canceled"
+	}, known)
+	_, _, _, err := store.Do(ctx, "user1", "key1", "a", func() (int, interface{}) {
+		t.Error("command executed again")
+		return 200, "foo"
+	}, known)
+	if !errors.Is(err, ErrOutcomeUnknown) {
+		t.Error(err)
+		return
+	}
+}
+
+func TestResultOfCanceledRequestIsKept(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(ctx, time.Minute)
+
+	requestCtx, cancelRequest := context.WithCancel(ctx)
+	cancelRequest()
+	store.Do(requestCtx, "user1", "key1", "a", func() (int, interface{}) {
+		return 200, "foo"
+	}, nil)
+	code, result, replayed, err := store.Do(ctx, "user1", "key1", "a", func() (int, interface{}) {
+		t.Error("command executed again")
+		return 200, "bar"
+	}, nil)
+	if err != nil || !replayed || code != 200 || result != "foo" {
+		t.Error(code, result, replayed, err)
+		return
+	}
+}
+
+func TestJoinerOfCanceledRequestDoesNotExecuteAgain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(ctx, time.Minute)
+
+	requestCtx, cancelRequest := context.WithCancel(ctx)
+	started := make(chan struct{})
+	go store.Do(requestCtx, "user1", "key1", "a", func() (int, interface{}) {
+		close(started)
+		<-requestCtx.Done()
+		return status.ClientClosedRequest, "canceled"
+	}, nil)
+	<-started
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancelRequest()
+	}()
+	_, _, _, err := store.Do(ctx, "user1", "key1", "a", func() (int, interface{}) {
+		t.Error("command executed again")
+		return 200, "foo"
+	}, nil)
+	if !errors.Is(err, ErrOutcomeUnknown) {
+		t.Error(err)
+		return
+	}
+}
@@ -21,9 +21,14 @@ import (
 	"github.com/SENERGY-Platform/device-command/pkg/api"
 	"github.com/SENERGY-Platform/device-command/pkg/command"
 	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/device-command/pkg/tracing"
 )
 
 func Start(ctx context.Context, config configuration.Config) error {
+	err := tracing.Init(ctx, config)
+	if err != nil {
+		return err
+	}
 	cmd, err := command.New(ctx, config)
 	if err != nil {
 		return err
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit allows Rate requests per second with bursts of up to Burst requests
+type Limit struct {
+	Rate  float64
+	Burst int64
+}
+
+// Key identifies a token bucket within a scope (e.g. Scope "user", Id "<user-id>")
+type Key struct {
+	Scope string
+	Id    string
+}
+
+// Limiter keeps a token bucket for each Key; scopes without a positive rate are not limited
+type Limiter struct {
+	limits  map[string]Limit
+	buckets map[Key]*bucket
+	mux     sync.Mutex
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func New(ctx context.Context, limits map[string]Limit) *Limiter {
+	result := &Limiter{limits: map[string]Limit{}, buckets: map[Key]*bucket{}}
+	for scope, limit := range limits {
+		if limit.Rate > 0 {
+			limit.Burst = max(limit.Burst, 1)
+			result.limits[scope] = limit
+		}
+	}
+	go result.cleanupLoop(ctx)
+	return result
+}
+
+// Allow takes one token from the bucket of each key.
+// if one of the buckets is empty, no token is taken and the scope of the empty bucket and the time until it refills are returned.
+func (this *Limiter) Allow(keys ...Key) (ok bool, scope string, retryAfter time.Duration) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	buckets := []*bucket{}
+	for _, key := range keys {
+		limit, limited := this.limits[key.Scope]
+		if !limited {
+			continue
+		}
+		b, exists := this.buckets[key]
+		if !exists {
+			b = &bucket{tokens: float64(limit.Burst), updated: now}
+			this.buckets[key] = b
+		}
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+		b.updated = now
+		if b.tokens < 1 {
+			wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+			if wait > retryAfter {
+				scope = key.Scope
+				retryAfter = wait
+			}
+			continue
+		}
+		buckets = append(buckets, b)
+	}
+	if retryAfter > 0 {
+		return false, scope, retryAfter
+	}
+	for _, b := range buckets {
+		b.tokens = b.tokens - 1
+	}
+	return true, "", 0
+}
+
+func (this *Limiter) cleanupLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			this.cleanup()
+		}
+	}
+}
+
+// cleanup removes buckets which are refilled completely; they would be recreated with the same state
+func (this *Limiter) cleanup() {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	now := time.Now()
+	for key, b := range this.buckets {
+		limit := this.limits[key.Scope]
+		if b.tokens+now.Sub(b.updated).Seconds()*limit.Rate >= float64(limit.Burst) {
+			delete(this.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	limiter := New(ctx, map[string]Limit{
+		"user":   {Rate: 10, Burst: 2},
+		"device": {Rate: 1, Burst: 3},
+		"none":   {Rate: 0, Burst: 1},
+	})
+
+	for i := 0; i < 2; i++ {
+		ok, _, _ := limiter.Allow(Key{Scope: "user", Id: "u1"}, Key{Scope: "device", Id: "d1"}, Key{Scope: "none", Id: "n1"})
+		if !ok {
+			t.Error(i)
+			return
+		}
+	}
+	ok, scope, retryAfter := limiter.Allow(Key{Scope: "user", Id: "u1"}, Key{Scope: "device", Id: "d1"})
+	if ok || scope != "user" || retryAfter <= 0 || retryAfter > 100*time.Millisecond {
+		t.Error(ok, scope, retryAfter)
+		return
+	}
+
+	//other user, same device: the device bucket has one token left
+	ok, _, _ = limiter.Allow(Key{Scope: "user", Id: "u2"}, Key{Scope: "device", Id: "d1"})
+	if !ok {
+		t.Error("expected last device token")
+		return
+	}
+	ok, scope, retryAfter = limiter.Allow(Key{Scope: "user", Id: "u2"}, Key{Scope: "device", Id: "d1"})
+	if ok || scope != "device" || retryAfter <= 500*time.Millisecond || retryAfter > time.Second {
+		t.Error(ok, scope, retryAfter)
+		return
+	}
+
+	//the rejected request did not take a user token
+	ok, _, _ = limiter.Allow(Key{Scope: "user", Id: "u2"}, Key{Scope: "device", Id: "d2"})
+	if !ok {
+		t.Error("expected second user token")
+		return
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	ok, _, _ = limiter.Allow(Key{Scope: "user", Id: "u1"}, Key{Scope: "device", Id: "d2"})
+	if !ok {
+		t.Error("expected refilled user token")
+		return
+	}
+
+	for i := 0; i < 100; i++ {
+		ok, _, _ = limiter.Allow(Key{Scope: "none", Id: "n1"})
+		if !ok {
+			t.Error(i)
+			return
+		}
+	}
+}
@@ -17,14 +17,25 @@
 package register
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/status"
+	"github.com/SENERGY-Platform/device-command/pkg/storage"
 )
 
 func New(defaultTimeout time.Duration, debug bool) *Register {
-	return &Register{register: map[string]*State{}, defaultTimeout: defaultTimeout, debug: debug}
+	return NewWithBackend(defaultTimeout, debug, storage.NewMemory[Entry]())
+}
+
+// NewWithBackend creates a Register which stores pending entries in backend.
+// entries found in a persistent backend after a restart may be completed by late responses, see Recover.
+func NewWithBackend(defaultTimeout time.Duration, debug bool, backend storage.Backend[Entry]) *Register {
+	return &Register{register: map[string]*State{}, recoverable: map[string]bool{}, defaultTimeout: defaultTimeout, debug: debug, backend: backend}
 }
 
 type Register struct {
@@ -32,38 +43,75 @@ type Register struct {
 	register       map[string]*State
 	mux            sync.Mutex
 	defaultTimeout time.Duration
+	backend        storage.Backend[Entry]
+	recovered      func(entry Entry, code int, value interface{})
+	recoverable    map[string]bool //ids of entries loaded by Recover which are not yet completed
 }
 
 type State struct {
-	wg   *sync.WaitGroup
-	code int
-	resp interface{}
+	wg        *sync.WaitGroup
+	code      int
+	resp      interface{}
+	completed bool
+}
+
+// Entry is the persisted part of a pending register state
+type Entry struct {
+	Id         string        `json:"id"`
+	TaskId     string        `json:"task_id,omitempty"` //optional async task waiting for this entry
+	Created    time.Time     `json:"created"`
+	Timeout    time.Duration `json:"timeout"`
+	UserId     string        `json:"user_id,omitempty"` //optional; describes the command for recovered completions (e.g. audit records)
+	DeviceId   string        `json:"device_id,omitempty"`
+	ServiceId  string        `json:"service_id,omitempty"`
+	FunctionId string        `json:"function_id,omitempty"`
 }
 
 func (this *Register) Register(id string) {
-	this.mux.Lock()
-	defer this.mux.Unlock()
+	this.RegisterForTask(Entry{Id: id, Timeout: this.defaultTimeout})
+}
+
+// RegisterForTask registers entry.Id and remembers the async task waiting for it,
+// which allows to complete the task with a late response after a restart
+// the entry is persisted without holding the register lock, to not serialize all commands on disk latency
+func (this *Register) RegisterForTask(entry Entry) {
+	id := entry.Id
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
+	this.mux.Lock()
 	this.register[id] = &State{
 		wg:   wg,
 		code: 0,
 		resp: nil,
 	}
+	this.mux.Unlock()
+	entry.Created = time.Now()
+	err := this.backend.Set(id, entry)
+	if err != nil {
+		log.Println("ERROR: unable to persist register entry", id, err)
+	}
 }
 
+// Complete sets the result of id; only the first completion is used, later ones (e.g. a response after the wait was canceled) are ignored
 func (this *Register) Complete(id string, code int, value interface{}) {
 	if this.debug {
 		log.Println("complete", id, code, value)
 	}
 	this.mux.Lock()
-	defer this.mux.Unlock()
 	state, ok := this.register[id]
 	if !ok {
+		this.mux.Unlock()
+		this.completeRecovered(id, code, value)
 		return
 	}
+	if state.completed {
+		this.mux.Unlock()
+		return
+	}
+	state.completed = true
 	state.resp, state.code = value, code
 	state.wg.Done()
+	this.mux.Unlock()
 }
 
 func (this *Register) Wait(id string) (int, interface{}) {
@@ -71,25 +119,120 @@ func (this *Register) Wait(id string) (int, interface{}) {
 }
 
 func (this *Register) WaitWithTimeout(id string, timeout time.Duration) (int, interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return this.WaitWithContext(ctx, id)
+}
+
+// Pending returns the number of registered entries which are not yet removed by their waiting request
+func (this *Register) Pending() int {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return len(this.register)
+}
+
+// WaitWithContext waits until id is completed or ctx is done.
+// the entry is removed in both cases; a late response for it is ignored
+func (this *Register) WaitWithContext(ctx context.Context, id string) (int, interface{}) {
 	this.mux.Lock()
 	state, ok := this.register[id]
 	this.mux.Unlock()
 	if !ok {
 		return http.StatusInternalServerError, "unregistered correlation id"
 	}
-	defer func() {
-		this.mux.Lock()
-		delete(this.register, id)
-		this.mux.Unlock()
-	}()
+	defer this.Remove(id)
 
-	t := time.AfterFunc(timeout, func() {
-		this.Complete(id, http.StatusRequestTimeout, "timeout")
+	stop := context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			this.Complete(id, http.StatusRequestTimeout, "timeout")
+		} else {
+			this.Complete(id, status.ClientClosedRequest, "canceled")
+		}
 	})
 	defer func() {
-		t.Stop()
+		stop()
 	}()
 
 	state.wg.Wait()
 	return state.code, state.resp
 }
+
+// Remove deletes id without waiting for it, e.g. if the message could not be sent
+func (this *Register) Remove(id string) {
+	this.mux.Lock()
+	delete(this.register, id)
+	this.mux.Unlock()
+	err := this.backend.Delete(id)
+	if err != nil {
+		log.Println("ERROR: unable to remove register entry", id, err)
+	}
+}
+
+// Recover handles entries left in the backend by a previous run.
+// handler is called with the late response of such an entry or with a timeout if none arrives in time.
+func (this *Register) Recover(handler func(entry Entry, code int, value interface{})) error {
+	entries, err := this.backend.List()
+	if err != nil {
+		return err
+	}
+	recoverable := []Entry{}
+	this.mux.Lock()
+	this.recovered = handler
+	for _, entry := range entries {
+		if _, current := this.register[entry.Id]; !current {
+			this.recoverable[entry.Id] = true
+			recoverable = append(recoverable, entry)
+		}
+	}
+	this.mux.Unlock()
+	for _, entry := range recoverable {
+		remaining := time.Until(entry.Created.Add(entry.Timeout))
+		if this.debug {
+			log.Println("recover register entry", entry.Id, entry.TaskId, remaining)
+		}
+		time.AfterFunc(max(remaining, 0), func() {
+			this.Complete(entry.Id, http.StatusRequestTimeout, "timeout")
+		})
+	}
+	return nil
+}
+
+// completeRecovered handles the first completion of an entry loaded by Recover
+func (this *Register) completeRecovered(id string, code int, value interface{}) {
+	this.mux.Lock()
+	recovered := this.recovered
+	pending := this.recoverable[id]
+	delete(this.recoverable, id)
+	this.mux.Unlock()
+	if recovered == nil || !pending {
+		return
+	}
+	entry, found, err := this.backend.Get(id)
+	if err != nil {
+		log.Println("ERROR: unable to load register entry", id, err)
+		return
+	}
+	if !found {
+		return
+	}
+	err = this.backend.Delete(id)
+	if err != nil {
+		log.Println("ERROR: unable to remove register entry", id, err)
+	}
+	go recovered(entry, code, value)
+}
+
+// PendingTaskIds lists the task ids of persisted entries
+func (this *Register) PendingTaskIds() (result map[string]bool, err error) {
+	entries, err := this.backend.List()
+	if err != nil {
+		return nil, err
+	}
+	result = map[string]bool{}
+	for _, entry := range entries {
+		if entry.TaskId != "" {
+			result[entry.TaskId] = true
+		}
+	}
+	return result, nil
+}
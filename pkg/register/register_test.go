@@ -0,0 +1,194 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package register
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/status"
+	"github.com/SENERGY-Platform/device-command/pkg/storage"
+)
+
+func TestRecoverAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := storage.NewFile[Entry](dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	before := NewWithBackend(time.Minute, false, backend)
+	before.RegisterForTask(Entry{Id: "late", TaskId: "task1", Timeout: time.Minute, UserId: "user1", DeviceId: "device1"})
+	before.RegisterForTask(Entry{Id: "lost", TaskId: "task2", Timeout: 100 * time.Millisecond, UserId: "user2"})
+	//no WaitWithTimeout call: simulates a restart while waiting
+
+	backend, err = storage.NewFile[Entry](dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	after := NewWithBackend(time.Minute, false, backend)
+	taskIds, err := after.PendingTaskIds()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !taskIds["task1"] || !taskIds["task2"] {
+		t.Error(taskIds)
+		return
+	}
+
+	type result struct {
+		taskId string
+		userId string
+		code   int
+		value  interface{}
+	}
+	results := make(chan result, 2)
+	err = after.Recover(func(entry Entry, code int, value interface{}) {
+		results <- result{taskId: entry.TaskId, userId: entry.UserId, code: code, value: value}
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	after.Complete("late", http.StatusOK, "foo")
+
+	received := map[string]result{}
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-results:
+			received[r.taskId] = r
+		case <-time.After(5 * time.Second):
+			t.Error("timeout")
+			return
+		}
+	}
+	if received["task1"].code != http.StatusOK || received["task1"].value != "foo" || received["task1"].userId != "user1" {
+		t.Error(received["task1"])
+	}
+	if received["task2"].code != http.StatusRequestTimeout || received["task2"].userId != "user2" {
+		t.Error(received["task2"])
+	}
+
+	entries, err := backend.List()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(entries) != 0 {
+		t.Error(entries)
+	}
+}
+
+func TestWaitWithCanceledContext(t *testing.T) {
+	r := New(time.Minute, false)
+	r.Register("id")
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	code, value := r.WaitWithContext(ctx, "id")
+	if code != status.ClientClosedRequest || value != "canceled" {
+		t.Error(code, value)
+		return
+	}
+	r.mux.Lock()
+	_, stillRegistered := r.register["id"]
+	r.mux.Unlock()
+	if stillRegistered {
+		t.Error("entry not removed after cancel")
+	}
+	r.Complete("id", http.StatusOK, "late") //late response must not panic
+}
+
+func TestPending(t *testing.T) {
+	r := New(time.Minute, false)
+	r.Register("a")
+	r.Register("b")
+	if pending := r.Pending(); pending != 2 {
+		t.Error(pending)
+		return
+	}
+	r.Complete("a", http.StatusOK, "ok")
+	r.WaitWithContext(context.Background(), "a")
+	if pending := r.Pending(); pending != 1 {
+		t.Error(pending)
+	}
+}
+
+func TestLateResponseIsNotRecovered(t *testing.T) {
+	backend, err := storage.NewFile[Entry](t.TempDir())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	r := NewWithBackend(time.Minute, false, backend)
+	recovered := make(chan string, 1)
+	err = r.Recover(func(entry Entry, code int, value interface{}) {
+		recovered <- entry.Id
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	r.Register("id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	r.WaitWithContext(ctx, "id")
+	r.Complete("id", http.StatusOK, "late")
+	select {
+	case id := <-recovered:
+		t.Error("late response of current run handled as recovered", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCompleteTwice(t *testing.T) {
+	reg := New(time.Minute, false)
+	reg.Register("id")
+	reg.Complete("id", http.StatusInternalServerError, "produce failed")
+	reg.Complete("id", http.StatusOK, "late")
+	code, resp := reg.Wait("id")
+	if code != http.StatusInternalServerError || resp != "produce failed" {
+		t.Error(code, resp)
+	}
+}
+
+func TestCancelAfterComplete(t *testing.T) {
+	reg := New(time.Minute, false)
+	reg.Register("id")
+	reg.Complete("id", http.StatusInternalServerError, "produce failed")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	code, _ := reg.WaitWithContext(ctx, "id")
+	if code != http.StatusInternalServerError {
+		t.Error(code)
+	}
+
+	reg.Register("id2")
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	code, _ = reg.WaitWithContext(ctx, "id2")
+	if code != status.ClientClosedRequest {
+		t.Error(code)
+	}
+	reg.Complete("id2", http.StatusOK, "late")
+}
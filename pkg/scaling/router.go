@@ -0,0 +1,221 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scaling
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/google/uuid"
+)
+
+const ModeShared = "shared"
+
+const SecretHeader = "X-Replica-Forward-Secret"
+
+const ResponsePath = "/replicas/responses"
+const ErrorPath = "/replicas/errors"
+
+// replica ids are appended to task ids: <uuid>@<replica-address>
+const separator = "@"
+
+// forwards are sent in the background by forwardWorkers; if forwardQueueSize messages are waiting, further messages are dropped
+const forwardQueueSize = 1000
+const forwardWorkers = 10
+const forwardTimeout = 2 * time.Second
+
+// Router stamps the address of the current replica into task ids
+// and forwards responses consumed from a shared topic to the replica waiting for them
+type Router struct {
+	enabled   bool
+	replicaId string
+	secret    string
+	pattern   *regexp.Regexp
+	client    *http.Client
+	queue     chan forward
+}
+
+type forward struct {
+	replicaId string
+	path      string
+	message   []byte
+}
+
+// ErrMissingSecret is returned for scaling_mode "shared" without replica_forward_secret, which would allow anyone to forge device responses
+var ErrMissingSecret = errors.New("replica_forward_secret is required for scaling_mode shared")
+
+// ErrMissingPattern is returned for scaling_mode "shared" without replica_address_pattern, which would send the secret to any address found in a task id
+var ErrMissingPattern = errors.New("replica_address_pattern is required for scaling_mode shared")
+
+// ErrReplicaNotAllowed is returned by Forward for replica ids not matching replica_address_pattern
+var ErrReplicaNotAllowed = errors.New("replica address is not allowed")
+
+// ErrForwardQueueFull is returned by Forward if too many messages are waiting to be forwarded
+var ErrForwardQueueFull = errors.New("forward queue is full")
+
+// New creates the router; in scaling_mode "shared" forwards are sent until ctx is done
+func New(ctx context.Context, config configuration.Config) (*Router, error) {
+	if config.ScalingMode != ModeShared {
+		return &Router{}, nil
+	}
+	if config.ReplicaForwardSecret == "" {
+		return nil, ErrMissingSecret
+	}
+	if config.ReplicaAddressPattern == "" || config.ReplicaAddressPattern == "-" {
+		return nil, ErrMissingPattern
+	}
+	pattern, err := regexp.Compile("^(?:" + config.ReplicaAddressPattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	replicaId := config.ReplicaAddress
+	if replicaId == "" || replicaId == "-" {
+		ip, err := getOutboundIp()
+		if err != nil {
+			return nil, err
+		}
+		replicaId = net.JoinHostPort(ip, config.ServerPort)
+	}
+	result := &Router{
+		enabled:   true,
+		replicaId: replicaId,
+		secret:    config.ReplicaForwardSecret,
+		pattern:   pattern,
+		client:    &http.Client{Timeout: forwardTimeout},
+		queue:     make(chan forward, forwardQueueSize),
+	}
+	for i := 0; i < forwardWorkers; i++ {
+		go result.forwardLoop(ctx)
+	}
+	return result, nil
+}
+
+func (this *Router) Enabled() bool {
+	return this.enabled
+}
+
+func (this *Router) ReplicaId() string {
+	return this.replicaId
+}
+
+func (this *Router) NewTaskId() string {
+	if !this.enabled {
+		return uuid.NewString()
+	}
+	return uuid.NewString() + separator + this.replicaId
+}
+
+// Owner returns the replica which created the task id and if it is the current replica
+func (this *Router) Owner(taskId string) (replicaId string, local bool) {
+	if !this.enabled {
+		return "", true
+	}
+	index := strings.LastIndex(taskId, separator)
+	if index < 0 {
+		return "", true
+	}
+	replicaId = taskId[index+len(separator):]
+	return replicaId, replicaId == this.replicaId
+}
+
+// Allowed checks if messages may be forwarded to replicaId: it must be a plain host:port matching replica_address_pattern
+func (this *Router) Allowed(replicaId string) bool {
+	if !this.enabled || strings.ContainsAny(replicaId, "/?#@\\ ") {
+		return false
+	}
+	host, port, err := net.SplitHostPort(replicaId)
+	if err != nil || host == "" || port == "" {
+		return false
+	}
+	return this.pattern.MatchString(replicaId)
+}
+
+// Forward queues the raw message to be sent to path of the replica; messages for not allowed replicas are rejected
+func (this *Router) Forward(replicaId string, path string, message []byte) error {
+	if !this.Allowed(replicaId) {
+		return ErrReplicaNotAllowed
+	}
+	select {
+	case this.queue <- forward{replicaId: replicaId, path: path, message: message}:
+		return nil
+	default:
+		return ErrForwardQueueFull
+	}
+}
+
+func (this *Router) forwardLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case f := <-this.queue:
+			err := this.send(ctx, f)
+			if err != nil {
+				log.Println("ERROR:", err)
+			}
+		}
+	}
+}
+
+func (this *Router) send(ctx context.Context, f forward) error {
+	target := url.URL{Scheme: "http", Host: f.replicaId, Path: f.path}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(f.message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SecretHeader, this.secret)
+	resp, err := this.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		temp, _ := io.ReadAll(resp.Body)
+		return errors.New("unable to forward message to " + f.replicaId + ": " + strings.TrimSpace(string(temp)))
+	}
+	return nil
+}
+
+// ValidSecret checks the secret of a forwarded message; empty secrets are never valid
+func (this *Router) ValidSecret(secret string) bool {
+	return this.enabled && secret != "" && this.secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(this.secret)) == 1
+}
+
+func getOutboundIp() (string, error) {
+	addresses, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+	for _, address := range addresses {
+		if ipNet, ok := address.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String(), nil
+		}
+	}
+	return "", errors.New("unable to find replica address, please set replica_address")
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package scaling
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+)
+
+func TestRouterForwardsToOwner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan string, 1)
+	var owner *Router
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if !owner.ValidSecret(request.Header.Get(SecretHeader)) {
+			http.Error(writer, "invalid secret", http.StatusUnauthorized)
+			return
+		}
+		if request.URL.Path != ResponsePath {
+			http.Error(writer, "unexpected path", http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(request.Body)
+		received <- string(body)
+	}))
+	defer server.Close()
+
+	var err error
+	owner, err = New(ctx, configuration.Config{ScalingMode: ModeShared, ReplicaAddress: strings.TrimPrefix(server.URL, "http://"), ReplicaForwardSecret: "secret", ReplicaAddressPattern: `127\.0\.0\.1:[0-9]+`})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	other, err := New(ctx, configuration.Config{ScalingMode: ModeShared, ReplicaAddress: "other:8080", ReplicaForwardSecret: "secret", ReplicaAddressPattern: `127\.0\.0\.1:[0-9]+`})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	taskId := owner.NewTaskId()
+	if _, local := owner.Owner(taskId); !local {
+		t.Error("expected owner to handle own task id", taskId)
+		return
+	}
+	replica, local := other.Owner(taskId)
+	if local || replica != owner.ReplicaId() {
+		t.Error(replica, local)
+		return
+	}
+	err = other.Forward(replica, ResponsePath, []byte(`{"foo":"bar"}`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if msg := <-received; msg != `{"foo":"bar"}` {
+		t.Error(msg)
+	}
+}
+
+func TestRouterDisabled(t *testing.T) {
+	router, err := New(context.Background(), configuration.Config{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	taskId := router.NewTaskId()
+	if strings.Contains(taskId, separator) {
+		t.Error(taskId)
+	}
+	if _, local := router.Owner("foo" + separator + "bar:8080"); !local {
+		t.Error("disabled router should handle all task ids locally")
+	}
+}
+
+func TestRouterRequiresSecret(t *testing.T) {
+	_, err := New(context.Background(), configuration.Config{ScalingMode: ModeShared, ReplicaAddress: "replica:8080", ReplicaAddressPattern: "replica:8080"})
+	if !errors.Is(err, ErrMissingSecret) {
+		t.Error(err)
+		return
+	}
+	_, err = New(context.Background(), configuration.Config{ScalingMode: ModeShared, ReplicaAddress: "replica:8080", ReplicaForwardSecret: "secret"})
+	if !errors.Is(err, ErrMissingPattern) {
+		t.Error(err)
+		return
+	}
+	router := &Router{enabled: true}
+	if router.ValidSecret("") {
+		t.Error("empty secret accepted")
+	}
+}
+
+func TestRouterRejectsForeignReplicas(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	router, err := New(ctx, configuration.Config{ScalingMode: ModeShared, ReplicaAddress: "replica-0:8080", ReplicaForwardSecret: "secret", ReplicaAddressPattern: `replica-[0-9]+:8080`})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, replicaId := range []string{"replica-1:8080"} {
+		if !router.Allowed(replicaId) {
+			t.Error("expected allowed", replicaId)
+		}
+	}
+	for _, replicaId := range []string{
+		"attacker:8080",
+		"replica-1:8080.attacker.com:80",
+		"replica-1:8080/foo",
+		"attacker@replica-1:8080",
+		"replica-1",
+		"",
+	} {
+		if router.Allowed(replicaId) {
+			t.Error("expected rejected", replicaId)
+		}
+		if err = router.Forward(replicaId, ResponsePath, []byte(`{}`)); !errors.Is(err, ErrReplicaNotAllowed) {
+			t.Error(replicaId, err)
+		}
+	}
+}
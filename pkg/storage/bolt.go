@@ -0,0 +1,92 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// OpenBolt opens the embedded database at path; the database may hold multiple backends, see NewBolt
+func OpenBolt(path string) (*bolt.DB, error) {
+	return bolt.Open(path, 0600, &bolt.Options{Timeout: 10 * time.Second})
+}
+
+// NewBolt returns a backend which stores its values as json in bucket of db.
+// concurrent writes are combined into one transaction, to avoid a disk sync per value.
+func NewBolt[T any](db *bolt.DB, bucket string) (*Bolt[T], error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Bolt[T]{db: db, bucket: []byte(bucket)}, nil
+}
+
+type Bolt[T any] struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+func (this *Bolt[T]) Set(id string, value T) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return this.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(this.bucket).Put([]byte(id), content)
+	})
+}
+
+func (this *Bolt[T]) Get(id string) (value T, found bool, err error) {
+	err = this.db.View(func(tx *bolt.Tx) error {
+		content := tx.Bucket(this.bucket).Get([]byte(id))
+		if content == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(content, &value)
+	})
+	return value, found && err == nil, err
+}
+
+func (this *Bolt[T]) Delete(id string) error {
+	return this.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(this.bucket).Delete([]byte(id))
+	})
+}
+
+func (this *Bolt[T]) List() (result []T, err error) {
+	err = this.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(this.bucket).ForEach(func(key, content []byte) error {
+			var value T
+			err := json.Unmarshal(content, &value)
+			if err != nil {
+				log.Println("WARNING: ignore invalid stored value", string(key), err)
+				return nil
+			}
+			result = append(result, value)
+			return nil
+		})
+	})
+	return result, err
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestBolt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := OpenBolt(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	backend, err := NewBolt[string](db, "test")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for _, id := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			err := backend.Set(id, "value-"+id)
+			if err != nil {
+				t.Error(err)
+			}
+		}(id)
+	}
+	wg.Wait()
+	err = backend.Delete("b")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = db.Close()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	db, err = OpenBolt(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer db.Close()
+	backend, err = NewBolt[string](db, "test")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	value, found, err := backend.Get("a")
+	if err != nil || !found || value != "value-a" {
+		t.Error(value, found, err)
+		return
+	}
+	_, found, err = backend.Get("b")
+	if err != nil || found {
+		t.Error(found, err)
+		return
+	}
+	list, err := backend.List()
+	if err != nil || len(list) != 2 {
+		t.Error(list, err)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const fileSuffix = ".json"
+
+// NewFile returns a backend which stores each value as json file in dir
+func NewFile[T any](dir string) (*File[T], error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &File[T]{dir: dir}, nil
+}
+
+type File[T any] struct {
+	dir string
+	mux sync.RWMutex
+}
+
+func (this *File[T]) path(id string) string {
+	return filepath.Join(this.dir, url.PathEscape(id)+fileSuffix)
+}
+
+func (this *File[T]) Set(id string, value T) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	//write to temp file and rename to prevent partially written files
+	temp, err := os.CreateTemp(this.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(content)
+	if err == nil {
+		err = temp.Sync()
+	}
+	closeErr := temp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), this.path(id))
+}
+
+func (this *File[T]) Get(id string) (value T, found bool, err error) {
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	content, err := os.ReadFile(this.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return value, false, nil
+	}
+	if err != nil {
+		return value, false, err
+	}
+	err = json.Unmarshal(content, &value)
+	return value, err == nil, err
+}
+
+func (this *File[T]) Delete(id string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	err := os.Remove(this.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (this *File[T]) List() (result []T, err error) {
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	entries, err := os.ReadDir(this.dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileSuffix) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(this.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var value T
+		err = json.Unmarshal(content, &value)
+		if err != nil {
+			log.Println("WARNING: ignore invalid stored value", entry.Name(), err)
+			continue
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"sync"
+)
+
+// Backend is a simple key-value store used to keep state that should survive restarts
+type Backend[T any] interface {
+	Set(id string, value T) error
+	Get(id string) (value T, found bool, err error)
+	Delete(id string) error
+	List() ([]T, error)
+}
+
+// New returns a file backend if dir is set, otherwise a memory backend
+func New[T any](dir string) (Backend[T], error) {
+	if dir == "" || dir == "-" {
+		return NewMemory[T](), nil
+	}
+	return NewFile[T](dir)
+}
+
+func NewMemory[T any]() *Memory[T] {
+	return &Memory[T]{values: map[string]T{}}
+}
+
+type Memory[T any] struct {
+	values map[string]T
+	mux    sync.RWMutex
+}
+
+func (this *Memory[T]) Set(id string, value T) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.values[id] = value
+	return nil
+}
+
+func (this *Memory[T]) Get(id string) (value T, found bool, err error) {
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	value, found = this.values[id]
+	return value, found, nil
+}
+
+func (this *Memory[T]) Delete(id string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.values, id)
+	return nil
+}
+
+func (this *Memory[T]) List() (result []T, err error) {
+	this.mux.RLock()
+	defer this.mux.RUnlock()
+	for _, value := range this.values {
+		result = append(result, value)
+	}
+	return result, nil
+}
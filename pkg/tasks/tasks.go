@@ -0,0 +1,239 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tasks
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/storage"
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+)
+
+var ErrNotFound = errors.New("task not found")
+
+type Status string
+
+const (
+	StatusPending               Status = "pending"
+	StatusDone                  Status = "done"
+	StatusCompletedAfterRestart Status = "completed after restart"
+)
+
+type Task struct {
+	Id          string      `json:"id"`
+	UserId      string      `json:"-"`
+	Status      Status      `json:"status"`
+	StatusCode  int         `json:"status_code,omitempty"`
+	Result      interface{} `json:"result,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	CompletedAt *time.Time  `json:"completed_at,omitempty"`
+}
+
+type storedTask struct {
+	Task
+	UserId string `json:"user_id"`
+}
+
+// Store keeps async command tasks; finished tasks are removed after the retention duration
+type Store struct {
+	tasks     map[string]*Task
+	cancels   map[string]context.CancelFunc
+	mux       sync.Mutex
+	retention time.Duration
+	backend   storage.Backend[storedTask]
+}
+
+func New(ctx context.Context, retention time.Duration) *Store {
+	result, _ := NewPersistent(ctx, retention, "")
+	return result
+}
+
+// NewPersistent creates a Store which keeps its tasks in dir; tasks of previous runs are loaded on creation
+func NewPersistent(ctx context.Context, retention time.Duration, dir string) (*Store, error) {
+	backend, err := storage.New[storedTask](dir)
+	if err != nil {
+		return nil, err
+	}
+	return newWithBackend(ctx, retention, backend)
+}
+
+// NewBolt creates a Store which keeps its tasks in the "tasks" bucket of db; tasks of previous runs are loaded on creation
+func NewBolt(ctx context.Context, retention time.Duration, db *bolt.DB) (*Store, error) {
+	backend, err := storage.NewBolt[storedTask](db, "tasks")
+	if err != nil {
+		return nil, err
+	}
+	return newWithBackend(ctx, retention, backend)
+}
+
+func newWithBackend(ctx context.Context, retention time.Duration, backend storage.Backend[storedTask]) (*Store, error) {
+	result := &Store{tasks: map[string]*Task{}, cancels: map[string]context.CancelFunc{}, retention: retention, backend: backend}
+	stored, err := backend.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, element := range stored {
+		task := element.Task
+		task.UserId = element.UserId
+		result.tasks[task.Id] = &task
+	}
+	go result.cleanupLoop(ctx)
+	return result, nil
+}
+
+func (this *Store) Create(userId string) Task {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	task := &Task{
+		Id:        uuid.NewString(),
+		UserId:    userId,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+	this.tasks[task.Id] = task
+	this.persist(task)
+	return *task
+}
+
+// CreateWithContext creates a task and a context derived from ctx; the context is canceled when the task is completed or removed
+func (this *Store) CreateWithContext(ctx context.Context, userId string) (Task, context.Context) {
+	task := this.Create(userId)
+	ctx, cancel := context.WithCancel(ctx)
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.cancels[task.Id] = cancel
+	return task, ctx
+}
+
+// Complete stores the result of the task; results of removed tasks are discarded and false is returned
+func (this *Store) Complete(id string, code int, result interface{}) bool {
+	return this.complete(id, StatusDone, code, result)
+}
+
+// CompleteAfterRestart stores a result which was received after a restart of the service
+func (this *Store) CompleteAfterRestart(id string, code int, result interface{}) bool {
+	return this.complete(id, StatusCompletedAfterRestart, code, result)
+}
+
+func (this *Store) complete(id string, status Status, code int, result interface{}) bool {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.cancel(id)
+	task, ok := this.tasks[id]
+	if !ok {
+		return false
+	}
+	now := time.Now()
+	task.Status = status
+	task.StatusCode = code
+	task.Result = result
+	task.CompletedAt = &now
+	this.persist(task)
+	return true
+}
+
+func (this *Store) Get(userId string, id string) (Task, error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	task, ok := this.tasks[id]
+	if !ok || task.UserId != userId {
+		return Task{}, ErrNotFound
+	}
+	return *task, nil
+}
+
+// ListPending returns all tasks without result
+func (this *Store) ListPending() (result []Task) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for _, task := range this.tasks {
+		if task.Status == StatusPending {
+			result = append(result, *task)
+		}
+	}
+	return result
+}
+
+// Remove deletes the task and cancels the context of CreateWithContext
+func (this *Store) Remove(userId string, id string) error {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	task, ok := this.tasks[id]
+	if !ok || task.UserId != userId {
+		return ErrNotFound
+	}
+	this.remove(id)
+	return nil
+}
+
+// expects locked mux
+func (this *Store) cancel(id string) {
+	if cancel, ok := this.cancels[id]; ok {
+		cancel()
+		delete(this.cancels, id)
+	}
+}
+
+// expects locked mux
+func (this *Store) remove(id string) {
+	this.cancel(id)
+	delete(this.tasks, id)
+	err := this.backend.Delete(id)
+	if err != nil {
+		log.Println("ERROR: unable to remove stored task", id, err)
+	}
+}
+
+// expects locked mux
+func (this *Store) persist(task *Task) {
+	err := this.backend.Set(task.Id, storedTask{Task: *task, UserId: task.UserId})
+	if err != nil {
+		log.Println("ERROR: unable to store task", task.Id, err)
+	}
+}
+
+func (this *Store) cleanupLoop(ctx context.Context) {
+	interval := this.retention / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			this.cleanup()
+		}
+	}
+}
+
+func (this *Store) cleanup() {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	for id, task := range this.tasks {
+		if task.CompletedAt != nil && time.Since(*task.CompletedAt) > this.retention {
+			this.remove(id)
+		}
+	}
+}
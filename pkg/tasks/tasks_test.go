@@ -0,0 +1,109 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tasks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(ctx, time.Second)
+
+	task := store.Create("user1")
+	if task.Status != StatusPending {
+		t.Error(task.Status)
+		return
+	}
+
+	_, err := store.Get("user2", task.Id)
+	if !errors.Is(err, ErrNotFound) {
+		t.Error(err)
+		return
+	}
+
+	store.Complete(task.Id, 200, "foo")
+	task, err = store.Get("user1", task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if task.Status != StatusDone || task.StatusCode != 200 || task.Result != "foo" {
+		t.Errorf("%#v", task)
+		return
+	}
+
+	store.mux.Lock()
+	past := time.Now().Add(-2 * time.Second)
+	store.tasks[task.Id].CompletedAt = &past
+	store.mux.Unlock()
+	store.cleanup()
+	_, err = store.Get("user1", task.Id)
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("expected task to be removed after retention", err)
+		return
+	}
+
+	task = store.Create("user1")
+	err = store.Remove("user1", task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	store.Complete(task.Id, 200, "bar")
+	_, err = store.Get("user1", task.Id)
+	if !errors.Is(err, ErrNotFound) {
+		t.Error(err)
+		return
+	}
+}
+
+func TestRemoveCancelsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := New(ctx, time.Minute)
+
+	task, taskCtx := store.CreateWithContext(ctx, "user1")
+	err := store.Remove("user1", task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	select {
+	case <-taskCtx.Done():
+	case <-time.After(time.Second):
+		t.Error("task context not canceled by Remove")
+		return
+	}
+
+	task, taskCtx = store.CreateWithContext(ctx, "user1")
+	if taskCtx.Err() != nil {
+		t.Error(taskCtx.Err())
+		return
+	}
+	if !store.Complete(task.Id, 200, "foo") {
+		t.Error("unable to complete task")
+		return
+	}
+	if taskCtx.Err() == nil {
+		t.Error("task context not released by Complete")
+	}
+}
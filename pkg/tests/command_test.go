@@ -1351,13 +1351,13 @@ func testCommand(scalingSuffix string, cloudTimescale bool) func(t *testing.T) {
 			FunctionId: "urn:infai:ses:measuring-function:00549f18-88b5-44c7-adb1-f558e8d53d1d",
 			DeviceId:   "urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866",
 			ServiceId:  "urn:infai:ses:service:36fd778e-b04d-4d72-bed5-1b77ed1164b9",
-		}, http.StatusRequestTimeout, `"timeout"`))
+		}, http.StatusRequestTimeout, `{"type":"urn:infai:ses:device-command:error:timeout","title":"timeout","status":408,"detail":"timeout","error_code":"timeout","stage":"wait_for_response","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:36fd778e-b04d-4d72-bed5-1b77ed1164b9","function_id":"urn:infai:ses:measuring-function:00549f18-88b5-44c7-adb1-f558e8d53d1d"}`))
 
 		t.Run("invalid command", sendCommand(config, command.CommandMessage{
 			FunctionId: "foobar",
 			DeviceId:   "urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866",
 			ServiceId:  "urn:infai:ses:service:6d6067a3-ed4e-45ec-a7eb-b1695340d2f1",
-		}, 500, `"unable to load function: unexpected statuscode 404: not found\n"`))
+		}, 500, `{"type":"urn:infai:ses:device-command:error:function_load_failed","title":"unable to load function","status":500,"detail":"unable to load function: unexpected statuscode 404: not found\n","error_code":"function_load_failed","stage":"load_function","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:6d6067a3-ed4e-45ec-a7eb-b1695340d2f1","function_id":"foobar"}`))
 
 		t.Run("device color", sendCommand(config, command.CommandMessage{
 			FunctionId: "urn:infai:ses:controlling-function:c54e2a89-1fb8-4ecb-8993-a7b40b355599",
@@ -1452,7 +1452,7 @@ func testCommand(scalingSuffix string, cloudTimescale bool) func(t *testing.T) {
 				DeviceId:   "color_event",
 				ServiceId:  "urn:infai:ses:service:color_event",
 			},
-		}, 200, `[{"status_code":200,"message":[null]},{"status_code":200,"message":[13]},{"status_code":408,"message":"timeout"},{"status_code":500,"message":"unable to load function: unexpected statuscode 404: not found\n"},{"status_code":200,"message":[null]},{"status_code":200,"message":[{"b":158,"g":166,"r":50}]},{"status_code":200,"message":[true]},{"status_code":200,"message":[null]},{"status_code":200,"message":[13]},{"status_code":200,"message":[true]}]`))
+		}, 200, `[{"status_code":200,"message":[null]},{"status_code":200,"message":[13]},{"status_code":408,"message":"timeout","error":{"type":"urn:infai:ses:device-command:error:timeout","title":"timeout","status":408,"detail":"timeout","error_code":"timeout","stage":"wait_for_response","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:36fd778e-b04d-4d72-bed5-1b77ed1164b9","function_id":"urn:infai:ses:measuring-function:00549f18-88b5-44c7-adb1-f558e8d53d1d"}},{"status_code":500,"message":"unable to load function: unexpected statuscode 404: not found\n","error":{"type":"urn:infai:ses:device-command:error:function_load_failed","title":"unable to load function","status":500,"detail":"unable to load function: unexpected statuscode 404: not found\n","error_code":"function_load_failed","stage":"load_function","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:6d6067a3-ed4e-45ec-a7eb-b1695340d2f1","function_id":"foobar"}},{"status_code":200,"message":[null]},{"status_code":200,"message":[{"b":158,"g":166,"r":50}]},{"status_code":200,"message":[true]},{"status_code":200,"message":[null]},{"status_code":200,"message":[13]},{"status_code":200,"message":[true]}]`))
 
 		zeroTimestamp := time.UnixMilli(0).Format(time.RFC3339)
 
@@ -0,0 +1,125 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/command"
+	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/interfaces"
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
+)
+
+func TestDeviceHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("../../config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.DeviceHistorySize = 3
+	config.DeviceHistoryDir = "-"
+
+	owner, err := generateParsedUserToken("owner")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	other, err := generateParsedUserToken("other")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	iotFactory := func(ctx context.Context, config configuration.Config) (interfaces.Iot, error) {
+		return historyIotMock{deniedToken: other.Jwt()}, nil
+	}
+	cmd, err := command.NewWithFactories(ctx, config, (&sharedTopicMock{}).ComFactory, scalingMarshallerFactory, iotFactory, scalingTimescaleFactory)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for i := 0; i < 5; i++ {
+		code, resp := cmd.Command(ctx, owner, command.CommandMessage{
+			FunctionId: scalingFunctionId,
+			DeviceId:   "device",
+			ServiceId:  "service",
+			Input:      i,
+		}, "10s", false)
+		if code != http.StatusOK {
+			t.Error(code, resp)
+			return
+		}
+	}
+
+	t.Run("size bound", func(t *testing.T) {
+		code, resp := cmd.GetDeviceHistory(ctx, owner, "device")
+		if code != http.StatusOK {
+			t.Error(code, resp)
+			return
+		}
+		entries, ok := resp.([]command.DeviceHistoryEntry)
+		if !ok || len(entries) != 3 {
+			t.Errorf("%#v", resp)
+			return
+		}
+		if entries[0].Command.Input != 2 || entries[2].Command.Input != 4 || entries[2].UserId != "owner" || entries[2].Result.StatusCode != http.StatusOK {
+			t.Errorf("%#v", entries)
+		}
+	})
+
+	t.Run("access check", func(t *testing.T) {
+		code, resp := cmd.GetDeviceHistory(ctx, other, "device")
+		if code == http.StatusOK {
+			t.Errorf("expected denied access, got %#v", resp)
+			return
+		}
+		commandErr, ok := resp.(*command.CommandError)
+		if !ok || commandErr.ErrorCode != command.ErrCodeDeviceLoad {
+			t.Errorf("%#v", resp)
+		}
+	})
+}
+
+func generateParsedUserToken(userId string) (auth.Token, error) {
+	tokenStr, err := generateUserTokenById(userId)
+	if err != nil {
+		return auth.Token{}, err
+	}
+	return auth.Parse(tokenStr)
+}
+
+// historyIotMock denies access to all devices for deniedToken
+type historyIotMock struct {
+	scalingIotMock
+	deniedToken string
+}
+
+func (this historyIotMock) GetDevice(ctx context.Context, token string, id string) (model.Device, error) {
+	if token == this.deniedToken {
+		return model.Device{}, errors.New("access denied")
+	}
+	return this.scalingIotMock.GetDevice(ctx, token, id)
+}
@@ -375,7 +375,7 @@ func testMgwCommandWithTimeoutAuth(t *testing.T, badBackend bool, fallbackPath s
 		FunctionId: "urn:infai:ses:measuring-function:00549f18-88b5-44c7-adb1-f558e8d53d1d",
 		DeviceId:   "urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866",
 		ServiceId:  "urn:infai:ses:service:36fd778e-b04d-4d72-bed5-1b77ed1164b9",
-	}, http.StatusRequestTimeout, `"timeout"`))
+	}, http.StatusRequestTimeout, `{"type":"urn:infai:ses:device-command:error:timeout","title":"timeout","status":408,"detail":"timeout","error_code":"timeout","stage":"wait_for_response","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:36fd778e-b04d-4d72-bed5-1b77ed1164b9","function_id":"urn:infai:ses:measuring-function:00549f18-88b5-44c7-adb1-f558e8d53d1d"}`))
 
 	t.Run("device color", sendCommand(config, command.CommandMessage{
 		FunctionId: "urn:infai:ses:controlling-function:c54e2a89-1fb8-4ecb-8993-a7b40b355599",
@@ -412,9 +412,9 @@ func testMgwCommandWithTimeoutAuth(t *testing.T, badBackend bool, fallbackPath s
 		GroupId:    "group_temperature",
 	}, 200, "[13,13,13]"))
 
-	expectedDeviceBatchResponse := `[{"status_code":200,"message":[null]},{"status_code":200,"message":[13]},{"status_code":408,"message":"timeout"},{"status_code":500,"message":"unable to load function: unexpected statuscode 404: not found\n"},{"status_code":200,"message":[null]},{"status_code":200,"message":[{"b":158,"g":166,"r":50}]},{"status_code":200,"message":[true]}]`
+	expectedDeviceBatchResponse := `[{"status_code":200,"message":[null]},{"status_code":200,"message":[13]},{"status_code":408,"message":"timeout","error":{"type":"urn:infai:ses:device-command:error:timeout","title":"timeout","status":408,"detail":"timeout","error_code":"timeout","stage":"wait_for_response","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:36fd778e-b04d-4d72-bed5-1b77ed1164b9","function_id":"urn:infai:ses:measuring-function:00549f18-88b5-44c7-adb1-f558e8d53d1d"}},{"status_code":500,"message":"unable to load function: unexpected statuscode 404: not found\n","error":{"type":"urn:infai:ses:device-command:error:function_load_failed","title":"unable to load function","status":500,"detail":"unable to load function: unexpected statuscode 404: not found\n","error_code":"function_load_failed","stage":"load_function","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:6d6067a3-ed4e-45ec-a7eb-b1695340d2f1","function_id":"foobar"}},{"status_code":200,"message":[null]},{"status_code":200,"message":[{"b":158,"g":166,"r":50}]},{"status_code":200,"message":[true]}]`
 	if badBackend {
-		expectedDeviceBatchResponse = `[{"status_code":200,"message":[null]},{"status_code":200,"message":[13]},{"status_code":408,"message":"timeout"},{"status_code":500,"message":"unable to load function: Get \"http://localhost:2/functions/foobar\": dial tcp 127.0.0.1:2: connect: connection refused"},{"status_code":200,"message":[null]},{"status_code":200,"message":[{"b":158,"g":166,"r":50}]},{"status_code":200,"message":[true]}]`
+		expectedDeviceBatchResponse = `[{"status_code":200,"message":[null]},{"status_code":200,"message":[13]},{"status_code":408,"message":"timeout","error":{"type":"urn:infai:ses:device-command:error:timeout","title":"timeout","status":408,"detail":"timeout","error_code":"timeout","stage":"wait_for_response","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:36fd778e-b04d-4d72-bed5-1b77ed1164b9","function_id":"urn:infai:ses:measuring-function:00549f18-88b5-44c7-adb1-f558e8d53d1d"}},{"status_code":500,"message":"unable to load function: Get \"http://localhost:2/functions/foobar\": dial tcp 127.0.0.1:2: connect: connection refused","error":{"type":"urn:infai:ses:device-command:error:function_load_failed","title":"unable to load function","status":500,"detail":"unable to load function: Get \"http://localhost:2/functions/foobar\": dial tcp 127.0.0.1:2: connect: connection refused","error_code":"function_load_failed","stage":"load_function","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:6d6067a3-ed4e-45ec-a7eb-b1695340d2f1","function_id":"foobar"}},{"status_code":200,"message":[null]},{"status_code":200,"message":[{"b":158,"g":166,"r":50}]},{"status_code":200,"message":[true]}]`
 	}
 	t.Run("device batch", sendCommandBatch(config, command.BatchRequest{
 		{
@@ -814,13 +814,13 @@ func TestMgwCommand(t *testing.T) {
 		FunctionId: "urn:infai:ses:measuring-function:00549f18-88b5-44c7-adb1-f558e8d53d1d",
 		DeviceId:   "urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866",
 		ServiceId:  "urn:infai:ses:service:36fd778e-b04d-4d72-bed5-1b77ed1164b9",
-	}, http.StatusRequestTimeout, `"timeout"`))
+	}, http.StatusRequestTimeout, `{"type":"urn:infai:ses:device-command:error:timeout","title":"timeout","status":408,"detail":"timeout","error_code":"timeout","stage":"wait_for_response","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:36fd778e-b04d-4d72-bed5-1b77ed1164b9","function_id":"urn:infai:ses:measuring-function:00549f18-88b5-44c7-adb1-f558e8d53d1d"}`))
 
 	t.Run("invalid command", sendCommand(config, command.CommandMessage{
 		FunctionId: "foobar",
 		DeviceId:   "urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866",
 		ServiceId:  "urn:infai:ses:service:6d6067a3-ed4e-45ec-a7eb-b1695340d2f1",
-	}, 500, `"unable to load function: value not found in fallback: function.foobar"`))
+	}, 500, `{"type":"urn:infai:ses:device-command:error:function_load_failed","title":"unable to load function","status":500,"detail":"unable to load function: value not found in fallback: function.foobar","error_code":"function_load_failed","stage":"load_function","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:6d6067a3-ed4e-45ec-a7eb-b1695340d2f1","function_id":"foobar"}`))
 
 	t.Run("device color", sendCommand(config, command.CommandMessage{
 		FunctionId: "urn:infai:ses:controlling-function:c54e2a89-1fb8-4ecb-8993-a7b40b355599",
@@ -899,7 +899,7 @@ func TestMgwCommand(t *testing.T) {
 			DeviceId:   "color_event",
 			ServiceId:  "urn:infai:ses:service:color_event",
 		},
-	}, 200, `[{"status_code":200,"message":[null]},{"status_code":200,"message":[13]},{"status_code":408,"message":"timeout"},{"status_code":500,"message":"unable to load function: value not found in fallback: function.foobar"},{"status_code":200,"message":[null]},{"status_code":200,"message":[{"b":158,"g":166,"r":50}]},{"status_code":200,"message":[true]}]`))
+	}, 200, `[{"status_code":200,"message":[null]},{"status_code":200,"message":[13]},{"status_code":408,"message":"timeout","error":{"type":"urn:infai:ses:device-command:error:timeout","title":"timeout","status":408,"detail":"timeout","error_code":"timeout","stage":"wait_for_response","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:36fd778e-b04d-4d72-bed5-1b77ed1164b9","function_id":"urn:infai:ses:measuring-function:00549f18-88b5-44c7-adb1-f558e8d53d1d"}},{"status_code":500,"message":"unable to load function: value not found in fallback: function.foobar","error":{"type":"urn:infai:ses:device-command:error:function_load_failed","title":"unable to load function","status":500,"detail":"unable to load function: value not found in fallback: function.foobar","error_code":"function_load_failed","stage":"load_function","retryable":true,"device_id":"urn:infai:ses:device:a486084b-3323-4cbc-9f6b-d797373ae866","service_id":"urn:infai:ses:service:6d6067a3-ed4e-45ec-a7eb-b1695340d2f1","function_id":"foobar"}},{"status_code":200,"message":[null]},{"status_code":200,"message":[{"b":158,"g":166,"r":50}]},{"status_code":200,"message":[true]}]`))
 
 	zeroTimestamp := time.UnixMilli(0).Format(time.RFC3339)
 
@@ -1160,7 +1160,7 @@ func TestMgwPlainTextCommandWithDockerTimescale(t *testing.T) {
 		FunctionId: "urn:infai:ses:measuring-function:20d3c1d3-77d7-4181-a9f3-b487add58cd0",
 		DeviceId:   "status_event_2",
 		ServiceId:  "urn:infai:ses:service:status_event",
-	}, 513, `"unable to get event value: missing last value in mgw-last-value"`))
+	}, 513, `{"type":"urn:infai:ses:device-command:error:missing_last_value","title":"missing last value","status":513,"detail":"unable to get event value: missing last value in mgw-last-value","error_code":"missing_last_value","stage":"last_event_value","retryable":false,"device_id":"status_event_2","service_id":"urn:infai:ses:service:status_event","function_id":"urn:infai:ses:measuring-function:20d3c1d3-77d7-4181-a9f3-b487add58cd0"}`))
 
 	t.Run("batch", sendCommandBatch(config, command.BatchRequest{
 		{
@@ -1173,7 +1173,7 @@ func TestMgwPlainTextCommandWithDockerTimescale(t *testing.T) {
 			DeviceId:   "status_event_2",
 			ServiceId:  "urn:infai:ses:service:status_event",
 		},
-	}, 200, `[{"status_code":200,"message":[true]},{"status_code":513,"message":"unable to get event value: missing last value in mgw-last-value"}]`))
+	}, 200, `[{"status_code":200,"message":[true]},{"status_code":513,"message":"unable to get event value: missing last value in mgw-last-value","error":{"type":"urn:infai:ses:device-command:error:missing_last_value","title":"missing last value","status":513,"detail":"unable to get event value: missing last value in mgw-last-value","error_code":"missing_last_value","stage":"last_event_value","retryable":false,"device_id":"status_event_2","service_id":"urn:infai:ses:service:status_event","function_id":"urn:infai:ses:measuring-function:20d3c1d3-77d7-4181-a9f3-b487add58cd0"}}]`))
 
 }
 
@@ -1394,7 +1394,7 @@ func TestShellyError(t *testing.T) {
 		FunctionId: "urn:infai:ses:measuring-function:20d3c1d3-77d7-4181-a9f3-b487add58cd0",
 		DeviceId:   "status_event_2",
 		ServiceId:  "urn:infai:ses:service:940fd269-27f7-4f2e-afbf-eddbf0feb4c8",
-	}, 513, `"unable to get event value: missing last value in mgw-last-value"`))
+	}, 513, `{"type":"urn:infai:ses:device-command:error:missing_last_value","title":"missing last value","status":513,"detail":"unable to get event value: missing last value in mgw-last-value","error_code":"missing_last_value","stage":"last_event_value","retryable":false,"device_id":"status_event_2","service_id":"urn:infai:ses:service:940fd269-27f7-4f2e-afbf-eddbf0feb4c8","function_id":"urn:infai:ses:measuring-function:20d3c1d3-77d7-4181-a9f3-b487add58cd0"}`))
 
 	t.Run("batch", sendCommandBatch(config, command.BatchRequest{
 		{
@@ -1407,7 +1407,7 @@ func TestShellyError(t *testing.T) {
 			DeviceId:   "status_event_2",
 			ServiceId:  "urn:infai:ses:service:940fd269-27f7-4f2e-afbf-eddbf0feb4c8",
 		},
-	}, 200, `[{"status_code":200,"message":[true]},{"status_code":513,"message":"unable to get event value: missing last value in mgw-last-value"}]`))
+	}, 200, `[{"status_code":200,"message":[true]},{"status_code":513,"message":"unable to get event value: missing last value in mgw-last-value","error":{"type":"urn:infai:ses:device-command:error:missing_last_value","title":"missing last value","status":513,"detail":"unable to get event value: missing last value in mgw-last-value","error_code":"missing_last_value","stage":"last_event_value","retryable":false,"device_id":"status_event_2","service_id":"urn:infai:ses:service:940fd269-27f7-4f2e-afbf-eddbf0feb4c8","function_id":"urn:infai:ses:measuring-function:20d3c1d3-77d7-4181-a9f3-b487add58cd0"}}]`))
 
 }
 
@@ -0,0 +1,251 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/api"
+	"github.com/SENERGY-Platform/device-command/pkg/auth"
+	"github.com/SENERGY-Platform/device-command/pkg/command"
+	"github.com/SENERGY-Platform/device-command/pkg/command/dependencies/interfaces"
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"github.com/SENERGY-Platform/device-command/pkg/scaling"
+	"github.com/SENERGY-Platform/external-task-worker/lib/devicerepository/model"
+	"github.com/SENERGY-Platform/external-task-worker/lib/marshaller"
+	"github.com/SENERGY-Platform/external-task-worker/lib/messages"
+	"github.com/SENERGY-Platform/models/go/models"
+)
+
+// TestSharedScalingMultipleInstances runs several Command instances in one process.
+// responses are always consumed by a different instance than the sender, like on a shared kafka topic,
+// and must be forwarded to the instance waiting for them.
+func TestSharedScalingMultipleInstances(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config, err := configuration.Load("../../config.json")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	config.ScalingMode = scaling.ModeShared
+	config.ReplicaForwardSecret = "secret"
+	config.ReplicaAddressPattern = `localhost:[0-9]+`
+
+	topic := &sharedTopicMock{}
+	instances := []*command.Command{}
+	addresses := []string{}
+	for i := 0; i < 3; i++ {
+		instanceConfig := config
+		instanceConfig.ServerPort, err = GetFreePort()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		instanceConfig.ReplicaAddress = "localhost:" + instanceConfig.ServerPort
+		cmd, err := command.NewWithFactories(ctx, instanceConfig, topic.ComFactory, scalingMarshallerFactory, scalingIotFactory, scalingTimescaleFactory)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		err = api.Start(ctx, instanceConfig, cmd)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		instances = append(instances, cmd)
+		addresses = append(addresses, instanceConfig.ReplicaAddress)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	tokenStr, err := generateUserTokenById("user")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	token, err := auth.Parse(tokenStr)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for i, cmd := range instances {
+		for j := 0; j < 3; j++ {
+			wg.Add(1)
+			go func(i int, cmd *command.Command) {
+				defer wg.Done()
+				code, resp := cmd.Command(ctx, token, command.CommandMessage{
+					FunctionId: scalingFunctionId,
+					DeviceId:   "device",
+					ServiceId:  "service",
+				}, "10s", false)
+				if code != http.StatusOK {
+					t.Error(i, code, resp)
+				}
+			}(i, cmd)
+		}
+	}
+	wg.Wait()
+
+	if topic.delivered() != 9 {
+		t.Error("unexpected number of delivered responses", topic.delivered())
+	}
+
+	for _, secret := range []string{"", "wrong"} {
+		req, err := http.NewRequest(http.MethodPost, "http://"+addresses[0]+scaling.ResponsePath, strings.NewReader(`{"task_info":{"task_id":"foo"}}`))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		req.Header.Set(scaling.SecretHeader, secret)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Error("forged response accepted with secret", secret, resp.StatusCode)
+		}
+	}
+}
+
+const scalingFunctionId = "urn:infai:ses:controlling-function:scaling-test"
+
+// sharedTopicMock delivers each command response to the response listener of the next instance after the sender
+type sharedTopicMock struct {
+	mux       sync.Mutex
+	listeners []func(msg messages.ProtocolMsg) error
+	count     int
+}
+
+func (this *sharedTopicMock) ComFactory(ctx context.Context, config configuration.Config, responseListener func(msg messages.ProtocolMsg) error, errorListener func(msg messages.ProtocolMsg) error) (interfaces.Producer, error) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.listeners = append(this.listeners, responseListener)
+	return &sharedTopicProducer{topic: this, index: len(this.listeners) - 1}, nil
+}
+
+func (this *sharedTopicMock) delivered() int {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	return this.count
+}
+
+type sharedTopicProducer struct {
+	topic *sharedTopicMock
+	index int
+}
+
+func (this *sharedTopicProducer) SendCommand(ctx context.Context, msg messages.ProtocolMsg) error {
+	this.topic.mux.Lock()
+	consumer := this.topic.listeners[(this.index+1)%len(this.topic.listeners)]
+	this.topic.count++
+	this.topic.mux.Unlock()
+	msg.Response.Output = map[string]string{}
+	go consumer(msg)
+	return nil
+}
+
+func scalingIotFactory(ctx context.Context, config configuration.Config) (interfaces.Iot, error) {
+	return scalingIotMock{}, nil
+}
+
+type scalingIotMock struct{}
+
+func (this scalingIotMock) GetDevice(ctx context.Context, token string, id string) (model.Device, error) {
+	return model.Device{Id: id, DeviceTypeId: "device-type"}, nil
+}
+
+func (this scalingIotMock) GetDeviceGroup(ctx context.Context, token string, id string) (model.DeviceGroup, error) {
+	return model.DeviceGroup{Id: id}, nil
+}
+
+func (this scalingIotMock) GetService(ctx context.Context, token string, device model.Device, id string) (model.Service, error) {
+	return model.Service{Id: id, ProtocolId: "protocol", Interaction: model.REQUEST}, nil
+}
+
+func (this scalingIotMock) GetDeviceType(ctx context.Context, token string, id string) (model.DeviceType, error) {
+	return model.DeviceType{Id: id}, nil
+}
+
+func (this scalingIotMock) GetProtocol(ctx context.Context, token string, id string) (model.Protocol, error) {
+	return model.Protocol{Id: id, Handler: "protocol"}, nil
+}
+
+func (this scalingIotMock) ListFunctions(ctx context.Context) ([]model.Function, error) {
+	return []model.Function{{Id: scalingFunctionId, RdfType: model.SES_ONTOLOGY_CONTROLLING_FUNCTION}}, nil
+}
+
+func (this scalingIotMock) GetFunction(ctx context.Context, id string) (model.Function, error) {
+	return model.Function{Id: id, RdfType: model.SES_ONTOLOGY_CONTROLLING_FUNCTION}, nil
+}
+
+func (this scalingIotMock) GetConcept(ctx context.Context, id string) (model.Concept, error) {
+	return model.Concept{Id: id}, nil
+}
+
+func (this scalingIotMock) GetCharacteristic(ctx context.Context, id string) (model.Characteristic, error) {
+	return model.Characteristic{Id: id}, nil
+}
+
+func (this scalingIotMock) GetAspectNode(ctx context.Context, id string) (model.AspectNode, error) {
+	return model.AspectNode{Id: id}, nil
+}
+
+func (this scalingIotMock) GetConceptIds(ctx context.Context) ([]string, error) {
+	return []string{}, nil
+}
+
+func scalingMarshallerFactory(ctx context.Context, config configuration.Config, iot interfaces.Iot) (interfaces.Marshaller, error) {
+	return scalingMarshallerMock{}, nil
+}
+
+type scalingMarshallerMock struct{}
+
+func (this scalingMarshallerMock) MarshalFromServiceAndProtocol(ctx context.Context, characteristicId string, service model.Service, protocol model.Protocol, characteristicData interface{}, configurables []marshaller.Configurable) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (this scalingMarshallerMock) UnmarshalFromServiceAndProtocol(ctx context.Context, characteristicId string, service model.Service, protocol model.Protocol, message map[string]string, hints []string) (interface{}, error) {
+	return nil, nil
+}
+
+func (this scalingMarshallerMock) MarshalV2(ctx context.Context, service model.Service, protocol model.Protocol, data []marshaller.MarshallingV2RequestData) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (this scalingMarshallerMock) UnmarshalV2(ctx context.Context, request marshaller.UnmarshallingV2Request) (interface{}, error) {
+	return nil, nil
+}
+
+func scalingTimescaleFactory(ctx context.Context, config configuration.Config) (interfaces.Timescale, error) {
+	return scalingTimescaleMock{}, nil
+}
+
+type scalingTimescaleMock struct{}
+
+func (this scalingTimescaleMock) GetLastMessage(ctx context.Context, token auth.Token, device models.Device, service models.Service, protocol model.Protocol) (map[string]interface{}, error) {
+	return nil, interfaces.ErrMissingLastValue
+}
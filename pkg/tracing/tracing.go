@@ -0,0 +1,178 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const TracerName = "github.com/SENERGY-Platform/device-command"
+
+// Init configures the global tracer provider and propagator for config.TracingExporter; spans are flushed when ctx is done.
+// without exporter, spans are not recorded but trace context of incoming requests is still propagated.
+func Init(ctx context.Context, config configuration.Config) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	var option sdktrace.TracerProviderOption
+	var file *os.File
+	switch config.TracingExporter {
+	case "", "-":
+		return nil
+	case "otlp":
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.TracingOtlpEndpoint))
+		if err != nil {
+			return err
+		}
+		option = sdktrace.WithBatcher(exporter)
+	case "stdout":
+		exporter, err := stdouttrace.New()
+		if err != nil {
+			return err
+		}
+		option = sdktrace.WithSyncer(exporter)
+	case "file":
+		var err error
+		file, err = os.OpenFile(config.TracingFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return err
+		}
+		option = sdktrace.WithSyncer(exporter)
+	default:
+		return errors.New("unknown tracing_exporter " + config.TracingExporter)
+	}
+	provider := sdktrace.NewTracerProvider(option, sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", config.TracingServiceName))))
+	otel.SetTracerProvider(provider)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := provider.Shutdown(shutdownCtx)
+		if err != nil {
+			log.Println("ERROR: unable to shutdown tracer provider", err)
+		}
+		if file != nil {
+			err = file.Close()
+			if err != nil {
+				log.Println("ERROR: unable to close tracing file", err)
+			}
+		}
+	}()
+	return nil
+}
+
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End marks the span as failed if err is set
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// EndWithStatusCode marks the span as failed if code is not a 2xx status code
+func EndWithStatusCode(span trace.Span, code int) {
+	span.SetAttributes(attribute.Int("status_code", code))
+	if code < 200 || code >= 300 {
+		span.SetStatus(codes.Error, "status code "+strconv.Itoa(code))
+	}
+	span.End()
+}
+
+// Inject returns the trace context of ctx as key-value pairs, e.g. to be sent in message metadata
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// Extract returns ctx with the trace context of carrier
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// KafkaHeaders returns the trace context of ctx as kafka message headers (traceparent, tracestate, baggage)
+func KafkaHeaders(ctx context.Context) (headers []sarama.RecordHeader) {
+	for key, value := range Inject(ctx) {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+	}
+	return headers
+}
+
+// Correlation keeps the span context of sent messages by task id, so that the handling of the response continues the trace
+// without relying on the protocol connector to return the trace context.
+type Correlation struct {
+	spans map[string]trace.SpanContext
+	mux   sync.Mutex
+}
+
+func NewCorrelation() *Correlation {
+	return &Correlation{spans: map[string]trace.SpanContext{}}
+}
+
+// Remember stores the span context of ctx for taskId; Forget must be called once the response is no longer expected
+func (this *Correlation) Remember(ctx context.Context, taskId string) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return
+	}
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	this.spans[taskId] = spanContext
+}
+
+// Context returns ctx with the span context remembered for taskId, if known
+func (this *Correlation) Context(ctx context.Context, taskId string) context.Context {
+	this.mux.Lock()
+	spanContext, ok := this.spans[taskId]
+	this.mux.Unlock()
+	if !ok {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, spanContext)
+}
+
+func (this *Correlation) Forget(taskId string) {
+	this.mux.Lock()
+	defer this.mux.Unlock()
+	delete(this.spans, taskId)
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2026 InfAI (CC SES)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SENERGY-Platform/device-command/pkg/configuration"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestResponseTraceCorrelation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	file := filepath.Join(t.TempDir(), "spans.json")
+	err := Init(ctx, configuration.Config{TracingExporter: "file", TracingFile: file, TracingServiceName: "test"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	correlation := NewCorrelation()
+	spanCtx, span := Start(ctx, "produce")
+	headers := KafkaHeaders(spanCtx)
+	correlation.Remember(spanCtx, "task")
+	End(span, nil)
+
+	carrier := map[string]string{}
+	for _, header := range headers {
+		carrier[string(header.Key)] = string(header.Value)
+	}
+	if !strings.Contains(carrier["traceparent"], span.SpanContext().TraceID().String()) {
+		t.Error("expected traceparent header", carrier)
+		return
+	}
+
+	responseCtx := correlation.Context(context.Background(), "task")
+	_, responseSpan := Start(responseCtx, "unmarshal")
+	EndWithStatusCode(responseSpan, 200)
+	if responseSpan.SpanContext().TraceID() != span.SpanContext().TraceID() {
+		t.Error("expected response span in trace of request", responseSpan.SpanContext().TraceID(), span.SpanContext().TraceID())
+		return
+	}
+	correlation.Forget("task")
+	if trace.SpanContextFromContext(correlation.Context(context.Background(), "task")).IsValid() {
+		t.Error("unexpected trace context after Forget")
+		return
+	}
+
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !strings.Contains(string(content), `"Name":"produce"`) || !strings.Contains(string(content), `"Name":"unmarshal"`) {
+		t.Error(string(content))
+		return
+	}
+}